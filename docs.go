@@ -0,0 +1,237 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/savaki/kafka-protocol-gen/protocol"
+)
+
+// Api pairs a request with its response
+type Api struct {
+	ApiKey   int
+	Name     string // Name of the api e.g. Fetch
+	Request  protocol.Message
+	Response *protocol.Message // Response, if one was defined
+}
+
+// DocField describes a field, at a specific version, for the reference docs
+type DocField struct {
+	Path     string // Path of the field relative to the message e.g. Topics.Partitions.Name
+	Depth    int    // Depth of nesting; 0 for top level fields
+	Type     string
+	Nullable bool
+	Tag      *int // Tag if the field is tagged in this version
+	Default  string
+	About    string
+}
+
+// VersionChange lists the differences between a version and its predecessor
+type VersionChange struct {
+	Version  int16
+	Added    []string // Added field paths
+	Removed  []string // Removed field paths
+	Nullable []string // Nullable field paths that were previously not nullable
+	Tagged   []string // Tagged field paths that were previously not tagged
+	Flexible bool     // Flexible is true if this is the first flexible version
+}
+
+// apis returns the apis defined by messages, ordered by api key
+func apis(messages []protocol.Message) []Api {
+	responses := map[int]protocol.Message{}
+	for _, message := range messages {
+		if message.Type == "response" {
+			responses[message.ApiKey] = message
+		}
+	}
+
+	var items []Api
+	for _, message := range messages {
+		if message.Type != "request" {
+			continue
+		}
+
+		api := Api{
+			ApiKey:  message.ApiKey,
+			Name:    baseName(message.Name),
+			Request: message,
+		}
+		if response, ok := responses[message.ApiKey]; ok {
+			api.Response = &response
+		}
+		items = append(items, api)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].ApiKey < items[j].ApiKey })
+	return items
+}
+
+// versionList returns each version within versions
+func versionList(versions protocol.ValidVersions) []int16 {
+	var items []int16
+	for version := versions.From; version <= versions.To; version++ {
+		items = append(items, version)
+	}
+	return items
+}
+
+// isFlexible returns true if the message uses the flexible encoding at version
+func isFlexible(message protocol.Message, version int16) bool {
+	return isFlexibleVersions(message.FlexibleVersions, version)
+}
+
+func isFlexibleVersions(flexibleVersions string, version int16) bool {
+	versions, err := protocol.ParseVersions(flexibleVersions)
+	return err == nil && versions != nil && versions.IsValid(version)
+}
+
+// isTagged returns true if the field is sent as a tagged field at version
+func isTagged(field protocol.Field, version int16) bool {
+	return field.TaggedVersions != nil && field.TaggedVersions.IsValid(version)
+}
+
+// docFields flattens the fields of message valid at version, depth first
+func docFields(message protocol.Message, version int16) []DocField {
+	var items []DocField
+
+	var walk func(prefix string, depth int, fields []protocol.Field)
+	walk = func(prefix string, depth int, fields []protocol.Field) {
+		for _, f := range fields {
+			if !f.Versions.IsValid(version) {
+				continue
+			}
+
+			item := DocField{
+				Path:     prefix + f.Name,
+				Depth:    depth,
+				Type:     f.Type,
+				Nullable: isNullable(f, version),
+				Default:  strings.Trim(string(f.Default), `"`),
+				About:    f.About,
+			}
+			if isTagged(f, version) {
+				item.Tag = f.Tag
+			}
+			items = append(items, item)
+
+			walk(item.Path+".", depth+1, structFields(message, f))
+		}
+	}
+	walk("", 0, message.Fields)
+
+	return items
+}
+
+// changeLog returns the changes introduced by each version of the message
+// after the first
+func changeLog(message protocol.Message, versions protocol.ValidVersions) []VersionChange {
+	var changes []VersionChange
+	for version := versions.From + 1; version <= versions.To; version++ {
+		var (
+			previous = map[string]DocField{}
+			current  = map[string]DocField{}
+			change   = VersionChange{
+				Version:  version,
+				Flexible: isFlexible(message, version) && !isFlexible(message, version-1),
+			}
+		)
+
+		for _, f := range docFields(message, version-1) {
+			previous[f.Path] = f
+		}
+		for _, f := range docFields(message, version) {
+			current[f.Path] = f
+
+			prev, ok := previous[f.Path]
+			switch {
+			case !ok:
+				change.Added = append(change.Added, f.Path)
+			case f.Nullable && !prev.Nullable:
+				change.Nullable = append(change.Nullable, f.Path)
+			}
+			if f.Tag != nil && (!ok || prev.Tag == nil) {
+				change.Tagged = append(change.Tagged, f.Path)
+			}
+		}
+		for _, f := range docFields(message, version-1) {
+			if _, ok := current[f.Path]; !ok {
+				change.Removed = append(change.Removed, f.Path)
+			}
+		}
+
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// bnf renders the wire layout of the message at version in the style of the
+// kafka protocol guide
+func bnf(message protocol.Message, version int16) string {
+	var (
+		flexible = isFlexible(message, version)
+		lines    []string
+	)
+
+	var walk func(name string, indent int, fields []protocol.Field)
+	walk = func(name string, indent int, fields []protocol.Field) {
+		var (
+			names    []string
+			children []func()
+		)
+		for _, f := range fields {
+			f := f
+			if !f.Versions.IsValid(version) || isTagged(f, version) {
+				continue
+			}
+
+			fieldName := snakeCase(f.Name)
+			if isArray(f.Type) {
+				names = append(names, "["+fieldName+"]")
+			} else {
+				names = append(names, fieldName)
+			}
+
+			if nested := structFields(message, f); len(nested) > 0 {
+				children = append(children, func() { walk(fieldName, indent+2, nested) })
+				continue
+			}
+
+			fieldFlexible := flexible
+			if f.FlexibleVersions != "" {
+				fieldFlexible = isFlexibleVersions(f.FlexibleVersions, version)
+			}
+			children = append(children, func() {
+				lines = append(lines, strings.Repeat(" ", indent+2)+fieldName+" => "+bnfType(f, version, fieldFlexible))
+			})
+		}
+		if flexible {
+			names = append(names, "TAG_BUFFER")
+		}
+
+		lines = append(lines, strings.TrimRight(strings.Repeat(" ", indent)+name+" => "+strings.Join(names, " "), " "))
+		for _, fn := range children {
+			fn()
+		}
+	}
+
+	walk(baseName(message.Name)+" "+capitalize(message.Type)+" (Version: "+strconv.Itoa(int(version))+")", 0, message.Fields)
+	return strings.Join(lines, "\n")
+}
+
+// bnfType returns the protocol guide name of a primitive field's wire type
+func bnfType(field protocol.Field, version int16, flexible bool) string {
+	t := strings.ToUpper(baseType(field.Type))
+	switch t {
+	case "BOOL":
+		t = "BOOLEAN"
+	case "STRING", "BYTES":
+		if isNullable(field, version) {
+			t = "NULLABLE_" + t
+		}
+		if flexible {
+			t = "COMPACT_" + t
+		}
+	}
+	return t
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/savaki/kafka-protocol-gen/protocol"
+)
+
+const docsTestMessage = `{
+  "apiKey": 99,
+  "type": "request",
+  "name": "ExampleRequest",
+  "validVersions": "0-2",
+  "flexibleVersions": "2+",
+  "fields": [
+    { "name": "ReplicaId", "type": "int32", "versions": "0+" },
+    { "name": "Topics", "type": "[]Topic", "versions": "0+", "fields": [
+      { "name": "Name", "type": "string", "versions": "0+", "nullableVersions": "1+" },
+      { "name": "Partitions", "type": "[]int32", "versions": "1+" }
+    ]},
+    { "name": "Removed", "type": "int64", "versions": "0" },
+    { "name": "Rack", "type": "string", "versions": "2+", "tag": 0, "taggedVersions": "2+" }
+  ]
+}`
+
+func parseDocsTestMessage(t *testing.T) protocol.Message {
+	message, err := protocol.Parse(strings.NewReader(docsTestMessage))
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	return message
+}
+
+func TestBNF(t *testing.T) {
+	message := parseDocsTestMessage(t)
+
+	testCases := map[string]struct {
+		Version int16
+		Want    string
+	}{
+		"v0": {
+			Version: 0,
+			Want: `Example Request (Version: 0) => replica_id [topics] removed
+  replica_id => INT32
+  topics => name
+    name => STRING
+  removed => INT64`,
+		},
+		"v2": {
+			Version: 2,
+			Want: `Example Request (Version: 2) => replica_id [topics] TAG_BUFFER
+  replica_id => INT32
+  topics => name [partitions] TAG_BUFFER
+    name => COMPACT_NULLABLE_STRING
+    partitions => INT32`,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			if got := bnf(message, tc.Version); got != tc.Want {
+				t.Fatalf("got\n%v\nwant\n%v", got, tc.Want)
+			}
+		})
+	}
+}
+
+func TestChangeLog(t *testing.T) {
+	message := parseDocsTestMessage(t)
+
+	got := changeLog(message, message.ValidVersions)
+	want := []VersionChange{
+		{
+			Version:  1,
+			Added:    []string{"Topics.Partitions"},
+			Removed:  []string{"Removed"},
+			Nullable: []string{"Topics.Name"},
+		},
+		{
+			Version:  2,
+			Added:    []string{"Rack"},
+			Tagged:   []string{"Rack"},
+			Flexible: true,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v; want %#v", got, want)
+	}
+}
+
+func TestApis(t *testing.T) {
+	messages := []protocol.Message{
+		{ApiKey: 1, Type: "response", Name: "FetchResponse"},
+		{ApiKey: 1, Type: "request", Name: "FetchRequest"},
+		{ApiKey: 0, Type: "request", Name: "ProduceRequest"},
+		{Type: "header", Name: "RequestHeader"},
+	}
+
+	got := apis(messages)
+	if len(got) != 2 {
+		t.Fatalf("got %v; want 2", len(got))
+	}
+	if got, want := got[0].Name, "Produce"; got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+	if got[0].Response != nil {
+		t.Fatalf("got %v; want nil", got[0].Response)
+	}
+	if got := got[1].Response; got == nil || got.Name != "FetchResponse" {
+		t.Fatalf("got %v; want FetchResponse", got)
+	}
+}
@@ -4,8 +4,10 @@ go 1.13
 
 require (
 	github.com/frankban/quicktest v1.4.1 // indirect
+	github.com/golang/snappy v0.0.1
+	github.com/klauspost/compress v1.10.3
 	github.com/kr/pretty v0.1.0
-	github.com/pierrec/lz4 v2.2.6+incompatible // indirect
+	github.com/pierrec/lz4 v2.2.6+incompatible
 	github.com/rakyll/statik v0.1.6
 	github.com/segmentio/kafka-go v0.3.4
 	github.com/segmentio/ksuid v1.0.2
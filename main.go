@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -24,12 +25,31 @@ const suffix = ".go"
 
 var opts struct {
 	dir       string
+	dryRun    bool // dryRun lists the files that would be created, updated, or deleted
 	module    string
-	src       string // src dir of protocol json files
-	templates string // templates contains optional directory of templates
-	last      int    // only include the last N versions; 0 means include all versions
+	redact    cli.StringSlice // redact holds additional paths of fields whose values are not printed
+	src       cli.StringSlice // src dirs or archives of protocol json files; later sources take precedence
+	templates string          // templates contains optional directory of templates
+	types     string          // types contains optional json file of type mappings
+	last      int             // only include the last N versions; 0 means include all versions
 }
 
+var dumpOpts struct {
+	out    string          // out file for the normalized definitions; defaults to stdout
+	schema string          // schema file for the JSON Schema of the definition format
+	src    cli.StringSlice // src dirs or archives of protocol json files; later sources take precedence
+}
+
+// defaultRedacted holds the paths, Message.Field.SubField with path.Match
+// wildcards, of fields that hold credentials
+var defaultRedacted = []string{
+	"*.AuthBytes",
+	"*.Hmac",
+}
+
+// typeMappings holds the go type overrides loaded from opts.types
+var typeMappings protocol.TypeMappings
+
 func main() {
 	app := cli.NewApp()
 	app.Flags = []cli.Flag{
@@ -39,6 +59,11 @@ func main() {
 			Usage:       "output directory",
 			Destination: &opts.dir,
 		},
+		cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "list files that would be created, updated, or deleted without writing them",
+			Destination: &opts.dryRun,
+		},
 		cli.IntFlag{
 			Name:        "last",
 			Usage:       "last N versions",
@@ -49,17 +74,50 @@ func main() {
 			Usage:       "module name",
 			Destination: &opts.module,
 		},
-		cli.StringFlag{
-			Name:        "src",
-			Value:       ".",
-			Usage:       "directory containing json kafka protocol definition",
-			Destination: &opts.src,
+		cli.StringSliceFlag{
+			Name:  "redact",
+			Usage: "additional field paths, e.g. *.Password, whose values are redacted when printed",
+			Value: &opts.redact,
+		},
+		cli.StringSliceFlag{
+			Name:  "src",
+			Usage: "directory, kafka checkout, or archive (.zip, .jar, .tar, .tgz) containing json kafka protocol definition; may be repeated with later sources taking precedence",
+			Value: &opts.src,
 		},
 		cli.StringFlag{
 			Name:        "templates",
 			Usage:       "optional directory of templates to render",
 			Destination: &opts.templates,
 		},
+		cli.StringFlag{
+			Name:        "types",
+			Usage:       "optional json file of go type mappings",
+			Destination: &opts.types,
+		},
+	}
+	app.Commands = []cli.Command{
+		{
+			Name:  "dump",
+			Usage: "write the normalized protocol definitions as json",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "out",
+					Usage:       "file to write normalized definitions to; defaults to stdout",
+					Destination: &dumpOpts.out,
+				},
+				cli.StringFlag{
+					Name:        "schema",
+					Usage:       "optional file to write the JSON Schema of the message definition format to",
+					Destination: &dumpOpts.schema,
+				},
+				cli.StringSliceFlag{
+					Name:  "src",
+					Usage: "directory, kafka checkout, or archive (.zip, .jar, .tar, .tgz) containing json kafka protocol definition; may be repeated with later sources taking precedence",
+					Value: &dumpOpts.src,
+				},
+			},
+			Action: dump,
+		},
 	}
 	app.EnableBashCompletion = true
 	app.Action = action
@@ -71,6 +129,14 @@ func main() {
 }
 
 func action(_ *cli.Context) error {
+	if opts.types != "" {
+		mappings, err := loadTypeMappings(opts.types)
+		if err != nil {
+			return err
+		}
+		typeMappings = mappings
+	}
+
 	dir, err := writeTemplates()
 	if err != nil {
 		return err
@@ -98,31 +164,8 @@ func action(_ *cli.Context) error {
 
 	fmt.Println(all.DefinedTemplates())
 
-	var messages []protocol.Message
-	callback := func(path string, info os.FileInfo, err error) error {
-		if info.IsDir() {
-			return nil
-		}
-		if !strings.HasSuffix(path, ".json") {
-			return nil
-		}
-
-		f, err := os.Open(path)
-		if err != nil {
-			return fmt.Errorf("unable to open file, %v: %w", path, err)
-		}
-		defer f.Close()
-
-		message, err := protocol.Parse(f)
-		if err != nil {
-			return fmt.Errorf("unable to parse file, %v: %w", path, err)
-		}
-
-		messages = append(messages, message)
-		return nil
-	}
-
-	if err := filepath.Walk(opts.src, callback); err != nil {
+	messages, err := loadMessages(opts.src)
+	if err != nil {
 		return err
 	}
 
@@ -134,6 +177,13 @@ func action(_ *cli.Context) error {
 		return ii.ApiKey < jj.ApiKey
 	})
 
+	imports := typeImports(messages, opts.last)
+
+	out, err := newOutputs(opts.dir, opts.dryRun)
+	if err != nil {
+		return err
+	}
+
 	walkFunc := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -151,6 +201,39 @@ func action(_ *cli.Context) error {
 		}
 
 		switch {
+		case strings.Contains(path, "{{.ApiName}}"):
+			for _, api := range apis(messages) {
+				rel := path
+				if strings.HasPrefix(rel, opts.templates) {
+					rel = rel[len(opts.templates):]
+				}
+				filename, err := interpolate(filepath.Join(opts.dir, rel), api.Request, api.ApiKey)
+				if err != nil {
+					return err
+				}
+
+				if ext := filepath.Ext(filename); strings.HasPrefix(ext, suffix) && len(ext) > len(suffix) {
+					filename = filename[0:len(filename)-len(ext)] + "." + ext[len(suffix):]
+				}
+
+				data := map[string]interface{}{
+					"Api":      api,
+					"Imports":  imports,
+					"Last":     opts.last,
+					"Messages": messages,
+					"Module":   opts.module,
+					"Package":  filepath.Base(opts.module),
+				}
+
+				buf := bytes.NewBuffer(nil)
+				if err := t.Execute(buf, data); err != nil {
+					return err
+				}
+
+				if err := out.Write(filename, buf.Bytes()); err != nil {
+					return err
+				}
+			}
 		case strings.Contains(path, "{{.MessageName}}"):
 			for _, message := range messages {
 				versions := protocol.ValidVersions{To: message.ValidVersions.To}
@@ -174,17 +257,8 @@ func action(_ *cli.Context) error {
 						filename = filename[0:len(filename)-len(ext)] + "." + ext[len(suffix):]
 					}
 
-					if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
-						return err
-					}
-
-					f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-					if err != nil {
-						return err
-					}
-					defer f.Close()
-
 					data := map[string]interface{}{
+						"Imports":  imports,
 						"Message":  message,
 						"Messages": messages,
 						"Module":   opts.module,
@@ -192,12 +266,12 @@ func action(_ *cli.Context) error {
 						"Versions": versions,
 					}
 
-					if err := t.Execute(f, data); err != nil {
+					buf := bytes.NewBuffer(nil)
+					if err := t.Execute(buf, data); err != nil {
 						return err
 					}
-					fmt.Println("wrote", filename)
 
-					return nil
+					return out.Write(filename, buf.Bytes())
 				}
 
 				if err := fn(); err != nil {
@@ -216,29 +290,20 @@ func action(_ *cli.Context) error {
 					filename = filename[0:len(filename)-len(ext)] + "." + ext[len(suffix):]
 				}
 
-				if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
-					return err
-				}
-
-				f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-				if err != nil {
-					return err
-				}
-				defer f.Close()
-
 				data := map[string]interface{}{
+					"Imports":  imports,
 					"Last":     opts.last,
 					"Messages": messages,
 					"Module":   opts.module,
 					"Package":  filepath.Base(opts.module),
 				}
 
-				if err := t.Execute(f, data); err != nil {
+				buf := bytes.NewBuffer(nil)
+				if err := t.Execute(buf, data); err != nil {
 					return err
 				}
-				fmt.Println("wrote", filename)
 
-				return nil
+				return out.Write(filename, buf.Bytes())
 			}
 
 			if err := fn(); err != nil {
@@ -249,37 +314,113 @@ func action(_ *cli.Context) error {
 		return nil
 	}
 
-	return filepath.Walk(dir, walkFunc)
+	if err := filepath.Walk(dir, walkFunc); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+// dump writes the normalized protocol definitions and, optionally, the JSON
+// Schema of the definition format
+func dump(_ *cli.Context) error {
+	messages, err := loadMessages(dumpOpts.src)
+	if err != nil {
+		return err
+	}
+
+	schema, err := protocol.Normalize(messages)
+	if err != nil {
+		return err
+	}
+
+	data, err := schema.MarshalIndent()
+	if err != nil {
+		return fmt.Errorf("unable to encode definitions: %w", err)
+	}
+
+	if dumpOpts.out == "" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return err
+		}
+	} else if err := ioutil.WriteFile(dumpOpts.out, data, 0644); err != nil {
+		return fmt.Errorf("unable to write definitions, %v: %w", dumpOpts.out, err)
+	}
+
+	if dumpOpts.schema != "" {
+		if err := ioutil.WriteFile(dumpOpts.schema, []byte(protocol.DefinitionJSONSchema), 0644); err != nil {
+			return fmt.Errorf("unable to write schema, %v: %w", dumpOpts.schema, err)
+		}
+	}
+
+	return nil
+}
+
+// loadMessages reads the message definitions from src, defaulting to the
+// current directory
+func loadMessages(src []string) ([]protocol.Message, error) {
+	if len(src) == 0 {
+		src = []string{"."}
+	}
+	return protocol.LoadSources(src...)
 }
 
 type VersionFields struct {
 	ApiKey   int
 	Fields   []protocol.Field
+	Message  protocol.Message // Message the struct belongs to
 	Name     string
+	Path     string // Path of the struct within the message e.g. FetchResponse.Topics
 	Versions protocol.ValidVersions
 }
 
 var funcMap = template.FuncMap{
+	"apis":             apis,
 	"baseName":         baseName,
 	"baseType":         baseType,
+	"bnf":              bnf,
 	"capitalize":       capitalize,
+	"changeLog":        changeLog,
+	"decodeField":      decodeField,
+	"docFields":        docFields,
+	"encodeField":      encodeField,
+	"equalExpr":        equalExpr,
+	"fieldType":        fieldType,
 	"findStructs":      findStructs,
 	"findStructFields": findStructFields,
 	"forVersion":       forVersion,
 	"goType":           goType,
+	"hasErrorCodes":    hasErrorCodes,
 	"hasFields":        hasFields,
+	"hasResponses":     hasResponses,
+	"hasStructArrays":  hasStructArrays,
 	"isArray":          isArray,
 	"isBytes":          isBytes,
+	"isErrorCode":      isErrorCode,
+	"isFlexible":       isFlexible,
+	"isMapped":         isMapped,
 	"isNullable":       isNullable,
 	"isPartialOverlap": isPartialOverlap,
+	"isFixedWidth":     isFixedWidth,
 	"isPrimitiveArray": isPrimitiveArray,
+	"isRedacted":       isRedacted,
 	"isRequest":        isRequest,
+	"isSlice":          isSlice,
 	"isString":         isString,
 	"isStructArray":    isStructArray,
+	"isTagged":         isTagged,
+	"join":             strings.Join,
+	"jsonName":         jsonName,
+	"markdown":         markdown,
+	"repeat":           strings.Repeat,
+	"snakeCase":        snakeCase,
+	"structFields":     structFields,
 	"structName":       structName,
 	"toVersionFields":  toVersionFields,
 	"type":             func(v string) string { return strings.ReplaceAll(v, "[]", "") },
 	"validVersions":    validVersions,
+	"versionList":      versionList,
+	"versionsExpr":     versionsExpr,
 }
 
 var reRequestResponse = regexp.MustCompile(`(Request|Response)$`)
@@ -301,28 +442,134 @@ func capitalize(v string) string {
 }
 
 func goType(t string) string {
+	if isArray(t) {
+		return "[]" + goType(strings.TrimPrefix(t, "[]"))
+	}
+
 	switch t {
-	case "bytes":
+	case "bytes", "records":
 		return "[]byte"
+	case "uuid":
+		return "UUID"
 	default:
 		return t
 	}
 }
 
+// decodeField returns the expression that converts the decoded wire value, v,
+// into the go type of the field
+func decodeField(path string, field protocol.Field, v string) string {
+	if m, ok := typeMappings.Lookup(path, field); ok {
+		return m.DecodeExpr(v)
+	}
+	return v
+}
+
+// encodeField returns the expression that converts the field value, v, into
+// its wire type
+func encodeField(path string, field protocol.Field, v string) string {
+	if m, ok := typeMappings.Lookup(path, field); ok {
+		return m.EncodeExpr(goType(field.Type), v)
+	}
+	return v
+}
+
+// equalExpr returns the expression that compares the non-struct field of a
+// and b by their wire values
+func equalExpr(path string, field protocol.Field, a, b string) string {
+	var (
+		name = field.Name
+		x    = encodeField(path, field, a+"."+name)
+		y    = encodeField(path, field, b+"."+name)
+	)
+	switch {
+	case isPrimitiveArray(field.Type):
+		return baseType(field.Type) + "ArrayEqual(" + x + ", " + y + ")"
+	case field.Type == "bytes" && field.NullableVersions != nil:
+		return "nullableBytesEqual(" + x + ", " + y + ", " + versionsExpr("version", field.NullableVersions) + ")"
+	case field.Type == "bytes", field.Type == "records", field.Type == "float64":
+		return field.Type + "Equal(" + x + ", " + y + ")"
+	default:
+		return x + " == " + y
+	}
+}
+
+// fieldType returns the go type of a non-struct field
+func fieldType(path string, field protocol.Field) string {
+	if m, ok := typeMappings.Lookup(path, field); ok {
+		return m.Type
+	}
+	return goType(field.Type)
+}
+
+// hasErrorCodes returns true if fields, or any struct nested within fields,
+// contains an error code
+func hasErrorCodes(message protocol.Message, versions protocol.ValidVersions, fields []protocol.Field) bool {
+	for _, f := range forVersion(versions, fields) {
+		if isErrorCode(f) {
+			return true
+		}
+		if hasErrorCodes(message, versions, structFields(message, f)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasStructArrays returns true if any of the messages contain an array of
+// structs within the versions being generated
+func hasStructArrays(messages []protocol.Message, last int) bool {
+	for _, message := range messages {
+		versions := validVersions(message, last)
+		for _, f := range forVersion(versions, message.Fields) {
+			if isStructArray(f.Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func hasFields(fields []protocol.Field) bool {
 	return len(fields) > 0
 }
 
+func hasResponses(messages []protocol.Message) bool {
+	for _, message := range messages {
+		if message.Type == "response" {
+			return true
+		}
+	}
+	return false
+}
+
 func isArray(t string) bool {
 	return strings.Contains(t, "[]")
 }
 
 func isBytes(t string) bool {
-	return t == "bytes"
+	return t == "bytes" || t == "records"
+}
+
+// isErrorCode returns true if the field holds a kafka error code
+func isErrorCode(field protocol.Field) bool {
+	return field.Type == "int16" && strings.HasSuffix(field.Name, "ErrorCode")
+}
+
+func isMapped(path string, field protocol.Field) bool {
+	_, ok := typeMappings.Lookup(path, field)
+	return ok
 }
 
+// isNullable returns true if the field may be null at version
 func isNullable(field protocol.Field, version int16) bool {
-	return true
+	return field.NullableVersions != nil && field.NullableVersions.IsValid(version)
+}
+
+// markdown escapes text for use within a markdown table cell
+func markdown(v string) string {
+	v = strings.ReplaceAll(v, "|", "\\|")
+	return strings.Join(strings.Fields(v), " ")
 }
 
 func isPartialOverlap(valid protocol.ValidVersions, versions protocol.Versions) bool {
@@ -337,8 +584,19 @@ func isPartialOverlap(valid protocol.ValidVersions, versions protocol.Versions)
 	return matches != want
 }
 
+// isFixedWidth returns true if the primitive type t is always encoded using
+// the same number of bytes
+func isFixedWidth(t string) bool {
+	switch t {
+	case "bool", "int8", "int16", "int32", "int64", "float64", "uuid":
+		return true
+	default:
+		return false
+	}
+}
+
 func isPrimitiveArray(t string) bool {
-	return t == "[]string" || t == "[]int32" || t == "[]int64"
+	return isArray(t) && protocol.IsPrimitive(t)
 }
 
 func isRequest(t string) bool {
@@ -349,10 +607,65 @@ func isString(t string) bool {
 	return t == "string"
 }
 
+// jsonName returns the name of the field as written by kafka's json
+// converters e.g. ThrottleTimeMs becomes throttleTimeMs
+func jsonName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return strings.ToLower(name[0:1]) + name[1:]
+}
+
+// versionsExpr returns the expression that tests whether the version held by
+// the variable, version, lies within versions; nil versions are never valid
+func versionsExpr(version string, versions *protocol.Versions) string {
+	if versions == nil {
+		return "false"
+	}
+	to := strconv.Itoa(int(versions.To))
+	if versions.UpToCurrent {
+		to = "-1"
+	}
+	return "inVersions(" + version + ", " + strconv.Itoa(int(versions.From)) + ", " + to + ")"
+}
+
+// isRedacted returns true if the value of the field is a secret that must not
+// be printed
+func isRedacted(parent string, field protocol.Field) bool {
+	fieldPath := parent + "." + field.Name
+	for _, pattern := range append(defaultRedacted, opts.redact...) {
+		if ok, _ := path.Match(pattern, fieldPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isSlice returns true if the go type of the field is a slice
+func isSlice(path string, field protocol.Field) bool {
+	return isArray(field.Type) || strings.HasPrefix(fieldType(path, field), "[]")
+}
+
 func isStructArray(t string) bool {
 	return isArray(t) && !isPrimitiveArray(t)
 }
 
+// structFields returns the fields of the struct, or array of structs,
+// referenced by field, either inline or by way of the message's common structs
+func structFields(message protocol.Message, field protocol.Field) []protocol.Field {
+	if len(field.Fields) > 0 {
+		return field.Fields
+	}
+
+	name := baseType(field.Type)
+	for _, f := range message.CommonStructs {
+		if f.Name == name {
+			return f.Fields
+		}
+	}
+	return nil
+}
+
 func structName(a string) string {
 	return strings.ReplaceAll(a, "[]", "")
 }
@@ -371,7 +684,9 @@ func toVersionFields(versions protocol.ValidVersions, message protocol.Message)
 	return VersionFields{
 		ApiKey:   message.ApiKey,
 		Fields:   message.Fields,
+		Message:  message,
 		Name:     message.Name,
+		Path:     message.Name,
 		Versions: versions,
 	}
 }
@@ -430,6 +745,7 @@ func interpolate(path string, message protocol.Message, apiKey int) (string, err
 
 	buf := bytes.NewBuffer(nil)
 	data := map[string]interface{}{
+		"ApiName":     snakeCase(baseName(message.Name)),
 		"MessageName": snakeCase(message.Name),
 		"ApiKey":      apiKey,
 	}
@@ -440,7 +756,7 @@ func interpolate(path string, message protocol.Message, apiKey int) (string, err
 	return buf.String(), nil
 }
 
-func findStructFields(apiKey int, versions protocol.ValidVersions, fields []protocol.Field) []VersionFields {
+func findStructFields(message protocol.Message, versions protocol.ValidVersions, path string, fields []protocol.Field) []VersionFields {
 	var structFields []VersionFields
 	for _, f := range fields {
 		if len(f.Fields) == 0 {
@@ -451,13 +767,15 @@ func findStructFields(apiKey int, versions protocol.ValidVersions, fields []prot
 		}
 
 		item := VersionFields{
-			ApiKey:   apiKey,
+			ApiKey:   message.ApiKey,
 			Fields:   f.Fields,
-			Name:     baseType(f.Type) + strconv.Itoa(apiKey),
+			Message:  message,
+			Name:     baseType(f.Type) + strconv.Itoa(message.ApiKey),
+			Path:     path + "." + f.Name,
 			Versions: versions,
 		}
 		structFields = append(structFields, item)
-		structFields = append(structFields, findStructFields(apiKey, versions, f.Fields)...)
+		structFields = append(structFields, findStructFields(message, versions, item.Path, f.Fields)...)
 	}
 	return structFields
 }
@@ -479,12 +797,14 @@ loop:
 }
 
 func findStructs(apiKey int, versions protocol.ValidVersions, message protocol.Message) []VersionFields {
-	structFields := findStructFields(apiKey, versions, message.Fields)
+	structFields := findStructFields(message, versions, message.Name, message.Fields)
 	for _, f := range message.CommonStructs {
 		item := VersionFields{
 			ApiKey:   apiKey,
 			Fields:   f.Fields,
+			Message:  message,
 			Name:     f.Name + strconv.Itoa(apiKey),
+			Path:     message.Name + "." + f.Name,
 			Versions: versions,
 		}
 		structFields = append(structFields, item)
@@ -492,6 +812,48 @@ func findStructs(apiKey int, versions protocol.ValidVersions, message protocol.M
 	return structFields
 }
 
+// loadTypeMappings reads the type mappings json file
+func loadTypeMappings(filename string) (protocol.TypeMappings, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return protocol.TypeMappings{}, fmt.Errorf("unable to open type mappings, %v: %w", filename, err)
+	}
+	defer f.Close()
+
+	return protocol.LoadTypeMappings(f)
+}
+
+// typeImports returns the sorted import paths required by the type mappings
+// that apply to the fields rendered for messages
+func typeImports(messages []protocol.Message, last int) []string {
+	seen := map[string]struct{}{}
+
+	var walk func(versions protocol.ValidVersions, path string, fields []protocol.Field)
+	walk = func(versions protocol.ValidVersions, path string, fields []protocol.Field) {
+		for _, f := range forVersion(versions, fields) {
+			if m, ok := typeMappings.Lookup(path, f); ok && m.Import != "" {
+				seen[m.Import] = struct{}{}
+			}
+			walk(versions, path+"."+f.Name, f.Fields)
+		}
+	}
+
+	for _, message := range messages {
+		versions := validVersions(message, last)
+		walk(versions, message.Name, message.Fields)
+		for _, f := range message.CommonStructs {
+			walk(versions, message.Name+"."+f.Name, f.Fields)
+		}
+	}
+
+	imports := make([]string, 0, len(seen))
+	for path := range seen {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+	return imports
+}
+
 func writeTemplates() (string, error) {
 	if opts.templates != "" {
 		return opts.templates, nil
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/savaki/kafka-protocol-gen/protocol"
+)
+
+func TestGoType(t *testing.T) {
+	testCases := map[string]string{
+		"bool":      "bool",
+		"bytes":     "[]byte",
+		"records":   "[]byte",
+		"uuid":      "UUID",
+		"[]int16":   "[]int16",
+		"[]bytes":   "[][]byte",
+		"[]uuid":    "[]UUID",
+		"[]Topic":   "[]Topic",
+		"[]float64": "[]float64",
+	}
+
+	for input, want := range testCases {
+		t.Run(input, func(t *testing.T) {
+			if got := goType(input); got != want {
+				t.Fatalf("got %v; want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestIsPrimitiveArray(t *testing.T) {
+	testCases := map[string]bool{
+		"int16":     false,
+		"[]bool":    true,
+		"[]int8":    true,
+		"[]int16":   true,
+		"[]int32":   true,
+		"[]int64":   true,
+		"[]float64": true,
+		"[]string":  true,
+		"[]bytes":   true,
+		"[]uuid":    true,
+		"[]Topic":   false,
+	}
+
+	for input, want := range testCases {
+		t.Run(input, func(t *testing.T) {
+			if got := isPrimitiveArray(input); got != want {
+				t.Fatalf("got %v; want %v", got, want)
+			}
+			if got := isStructArray(input); got == want && isArray(input) {
+				t.Fatalf("got %v; want %v", got, !want)
+			}
+		})
+	}
+}
+
+func TestIsSlice(t *testing.T) {
+	testCases := map[string]bool{
+		"bool":     false,
+		"string":   false,
+		"uuid":     false,
+		"bytes":    true,
+		"records":  true,
+		"[]int32":  true,
+		"[]string": true,
+		"[]Topic":  true,
+	}
+
+	for input, want := range testCases {
+		t.Run(input, func(t *testing.T) {
+			field := protocol.Field{Name: "Field", Type: input}
+			if got := isSlice("Message", field); got != want {
+				t.Fatalf("got %v; want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEqualExpr(t *testing.T) {
+	testCases := map[string]string{
+		"int32":   "t.Field == o.Field",
+		"string":  "t.Field == o.Field",
+		"uuid":    "t.Field == o.Field",
+		"float64": "float64Equal(t.Field, o.Field)",
+		"bytes":   "bytesEqual(t.Field, o.Field)",
+		"records": "recordsEqual(t.Field, o.Field)",
+		"[]int32": "int32ArrayEqual(t.Field, o.Field)",
+		"[]bytes": "bytesArrayEqual(t.Field, o.Field)",
+	}
+
+	for input, want := range testCases {
+		t.Run(input, func(t *testing.T) {
+			field := protocol.Field{Name: "Field", Type: input}
+			if got := equalExpr("Message", field, "t", "o"); got != want {
+				t.Fatalf("got %v; want %v", got, want)
+			}
+		})
+	}
+
+	t.Run("nullable bytes", func(t *testing.T) {
+		field := protocol.Field{Name: "Field", Type: "bytes", NullableVersions: &protocol.Versions{From: 1, UpToCurrent: true}}
+		want := "nullableBytesEqual(t.Field, o.Field, inVersions(version, 1, -1))"
+		if got := equalExpr("Message", field, "t", "o"); got != want {
+			t.Fatalf("got %v; want %v", got, want)
+		}
+	})
+}
+
+func TestHasErrorCodes(t *testing.T) {
+	all := protocol.Versions{UpToCurrent: true}
+	message := protocol.Message{
+		CommonStructs: []protocol.Field{
+			{
+				Name: "Result",
+				Fields: []protocol.Field{
+					{Name: "ErrorCode", Type: "int16", Versions: all},
+				},
+			},
+		},
+	}
+	versions := protocol.ValidVersions{To: 1}
+
+	testCases := map[string]struct {
+		Field protocol.Field
+		Want  bool
+	}{
+		"primitive":    {Field: protocol.Field{Name: "Count", Type: "int32", Versions: all}},
+		"error code":   {Field: protocol.Field{Name: "ErrorCode", Type: "int16", Versions: all}, Want: true},
+		"common":       {Field: protocol.Field{Name: "Result", Type: "Result", Versions: all}, Want: true},
+		"common array": {Field: protocol.Field{Name: "Results", Type: "[]Result", Versions: all}, Want: true},
+		"inline": {
+			Field: protocol.Field{
+				Name:     "Inline",
+				Type:     "Inline",
+				Versions: all,
+				Fields: []protocol.Field{
+					{Name: "ErrorCode", Type: "int16", Versions: all},
+				},
+			},
+			Want: true,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			if got := hasErrorCodes(message, versions, []protocol.Field{tc.Field}); got != tc.Want {
+				t.Fatalf("got %v; want %v", got, tc.Want)
+			}
+		})
+	}
+}
+
+func TestIsRedacted(t *testing.T) {
+	testCases := map[string]bool{
+		"SaslAuthenticateRequest.AuthBytes":              true,
+		"DescribeDelegationTokenResponse.Tokens.Hmac":    true,
+		"DescribeDelegationTokenResponse.Tokens.TokenId": false,
+		"FetchRequest.ReplicaId":                         false,
+	}
+
+	for input, want := range testCases {
+		t.Run(input, func(t *testing.T) {
+			i := strings.LastIndex(input, ".")
+			field := protocol.Field{Name: input[i+1:], Type: "bytes"}
+			if got := isRedacted(input[:i], field); got != want {
+				t.Fatalf("got %v; want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestJsonName(t *testing.T) {
+	testCases := map[string]string{
+		"":               "",
+		"ThrottleTimeMs": "throttleTimeMs",
+		"Topics":         "topics",
+	}
+
+	for input, want := range testCases {
+		t.Run(input, func(t *testing.T) {
+			if got := jsonName(input); got != want {
+				t.Fatalf("got %v; want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestVersionsExpr(t *testing.T) {
+	testCases := map[string]struct {
+		Versions *protocol.Versions
+		Want     string
+	}{
+		"nil":   {Want: "false"},
+		"range": {Versions: &protocol.Versions{From: 1, To: 3}, Want: "inVersions(version, 1, 3)"},
+		"plus":  {Versions: &protocol.Versions{From: 2, UpToCurrent: true}, Want: "inVersions(version, 2, -1)"},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			if got := versionsExpr("version", tc.Versions); got != tc.Want {
+				t.Fatalf("got %v; want %v", got, tc.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// manifestFilename holds the name of the manifest written to the output directory
+const manifestFilename = ".kafka-protocol-gen.json"
+
+// manifest records the files produced by a run along with their content hashes
+type manifest struct {
+	Files map[string]string `json:"files"` // Files maps path, relative to the output dir, to sha256
+}
+
+// outputs writes generated files, skipping files whose content is unchanged,
+// and prunes files produced by a previous run that are no longer generated.
+// A stale file is only pruned if its content still matches the hash recorded
+// by the previous run; files edited by hand are kept.
+type outputs struct {
+	dir      string
+	dryRun   bool
+	log      func(action, filename string)
+	previous manifest
+	current  manifest
+}
+
+func newOutputs(dir string, dryRun bool) (*outputs, error) {
+	o := &outputs{
+		dir:      filepath.Clean(dir),
+		dryRun:   dryRun,
+		log:      func(action, filename string) { fmt.Println(action, filename) },
+		previous: manifest{Files: map[string]string{}},
+		current:  manifest{Files: map[string]string{}},
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, manifestFilename))
+	if os.IsNotExist(err) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &o.previous); err != nil {
+		return nil, fmt.Errorf("unable to parse manifest: %w", err)
+	}
+	if o.previous.Files == nil {
+		o.previous.Files = map[string]string{}
+	}
+
+	return o, nil
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// inside returns the path of filename relative to the output dir or an error
+// if filename lies outside of it
+func (o *outputs) inside(filename string) (string, error) {
+	rel, err := filepath.Rel(o.dir, filename)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve file, %v: %w", filename, err)
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", fmt.Errorf("file lies outside output dir, %v", filename)
+	}
+	return rel, nil
+}
+
+// Write the generated content to filename unless the file already holds data
+func (o *outputs) Write(filename string, data []byte) error {
+	rel, err := o.inside(filename)
+	if err != nil {
+		return fmt.Errorf("unable to write file: %w", err)
+	}
+	o.current.Files[filepath.ToSlash(rel)] = hash(data)
+
+	existing, err := ioutil.ReadFile(filename)
+	switch {
+	case err == nil && bytes.Equal(existing, data):
+		return nil // unchanged; leave mtime intact
+	case err == nil:
+		o.log("update", filename)
+	case os.IsNotExist(err):
+		o.log("create", filename)
+	default:
+		return fmt.Errorf("unable to read file, %v: %w", filename, err)
+	}
+
+	if o.dryRun {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// Close deletes stale files and records the manifest for the next run
+func (o *outputs) Close() error {
+	var stale []string
+	for rel := range o.previous.Files {
+		if _, ok := o.current.Files[rel]; !ok {
+			stale = append(stale, rel)
+		}
+	}
+	sort.Strings(stale)
+
+	for _, rel := range stale {
+		filename := filepath.Join(o.dir, filepath.FromSlash(rel))
+		if _, err := o.inside(filename); err != nil || filepath.IsAbs(filepath.FromSlash(rel)) {
+			return fmt.Errorf("unable to delete stale file, manifest holds path outside output dir, %v", rel)
+		}
+
+		existing, err := ioutil.ReadFile(filename)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read stale file, %v: %w", filename, err)
+		}
+		if hash(existing) != o.previous.Files[rel] {
+			o.log("keep", filename) // modified since generated
+			continue
+		}
+
+		o.log("delete", filename)
+		if o.dryRun {
+			continue
+		}
+		if err := os.Remove(filename); err != nil {
+			return fmt.Errorf("unable to delete stale file, %v: %w", filename, err)
+		}
+
+		// remove directories left empty; os.Remove refuses non-empty directories
+		for dir := filepath.Dir(filename); dir != o.dir && dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+			if err := os.Remove(dir); err != nil {
+				break
+			}
+		}
+	}
+
+	if o.dryRun {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(o.current, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode manifest: %w", err)
+	}
+	if err := os.MkdirAll(o.dir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(o.dir, manifestFilename), append(data, '\n'), 0644)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOutputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outputs-")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		a = filepath.Join(dir, "a.go")
+		b = filepath.Join(dir, "sub", "b.go")
+	)
+
+	run := func(dryRun bool, files map[string]string) []string {
+		out, err := newOutputs(dir, dryRun)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		var actions []string
+		out.log = func(action, filename string) {
+			rel, _ := filepath.Rel(dir, filename)
+			actions = append(actions, action+" "+filepath.ToSlash(rel))
+		}
+
+		for _, filename := range []string{a, b} {
+			if content, ok := files[filename]; ok {
+				if err := out.Write(filename, []byte(content)); err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+			}
+		}
+		if err := out.Close(); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		return actions
+	}
+
+	t.Run("create", func(t *testing.T) {
+		got := run(false, map[string]string{a: "a", b: "b"})
+		if want := []string{"create a.go", "create sub/b.go"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v; want %v", got, want)
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour).Truncate(time.Second)
+		if err := os.Chtimes(a, past, past); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		got := run(false, map[string]string{a: "a", b: "b"})
+		if len(got) != 0 {
+			t.Fatalf("got %v; want no actions", got)
+		}
+
+		info, err := os.Stat(a)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if !info.ModTime().Equal(past) {
+			t.Fatalf("got %v; want %v", info.ModTime(), past)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		got := run(true, map[string]string{a: "changed"})
+		if want := []string{"update a.go", "delete sub/b.go"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v; want %v", got, want)
+		}
+		if _, err := os.Stat(b); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		got := run(false, map[string]string{a: "changed"})
+		if want := []string{"update a.go", "delete sub/b.go"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v; want %v", got, want)
+		}
+		if _, err := os.Stat(filepath.Dir(b)); !os.IsNotExist(err) {
+			t.Fatalf("got %v; want not exist", err)
+		}
+
+		data, err := ioutil.ReadFile(a)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if got, want := string(data), "changed"; got != want {
+			t.Fatalf("got %v; want %v", got, want)
+		}
+	})
+
+	t.Run("keep modified", func(t *testing.T) {
+		run(false, map[string]string{a: "a", b: "b"})
+		if err := ioutil.WriteFile(b, []byte("edited"), 0644); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		got := run(false, map[string]string{a: "a"})
+		if want := []string{"keep sub/b.go"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v; want %v", got, want)
+		}
+		if _, err := os.Stat(b); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	})
+
+	t.Run("outside", func(t *testing.T) {
+		out, err := newOutputs(dir, true)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := out.Write(filepath.Join(dir, "..", "x.go"), nil); err == nil {
+			t.Fatalf("got nil; want error")
+		}
+
+		out.previous.Files["../x.go"] = hash(nil)
+		if err := out.Close(); err == nil {
+			t.Fatalf("got nil; want error")
+		}
+	})
+}
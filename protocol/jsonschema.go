@@ -0,0 +1,120 @@
+package protocol
+
+// DefinitionJSONSchema holds the JSON Schema describing the kafka message
+// definition format read by Parse.  Definitions must have their comments
+// stripped before being validated against the schema.
+const DefinitionJSONSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "Kafka message definition",
+  "description": "Describes a single kafka protocol request, response, or header as found in clients/src/main/resources/common/message",
+  "type": "object",
+  "required": ["type", "name", "validVersions", "fields"],
+  "properties": {
+    "apiKey": {
+      "description": "Unique 16 bit identifier of the api; absent for headers",
+      "type": "integer",
+      "minimum": 0,
+      "maximum": 32767
+    },
+    "type": {
+      "description": "Kind of message",
+      "enum": ["request", "response", "header", "data"]
+    },
+    "name": {
+      "description": "Name of the message e.g. FetchRequest",
+      "type": "string",
+      "pattern": "^[A-Z][A-Za-z0-9]*$"
+    },
+    "validVersions": {
+      "description": "Versions of the message that are understood",
+      "$ref": "#/definitions/validVersions"
+    },
+    "flexibleVersions": {
+      "description": "Versions that use the flexible (compact, tagged) encoding",
+      "$ref": "#/definitions/flexibleVersions"
+    },
+    "fields": {
+      "type": "array",
+      "items": { "$ref": "#/definitions/field" }
+    },
+    "commonStructs": {
+      "description": "Structs that may be referenced by name from any field of the message",
+      "type": "array",
+      "items": { "$ref": "#/definitions/struct" }
+    }
+  },
+  "definitions": {
+    "validVersions": {
+      "type": "string",
+      "pattern": "^[0-9]+(-[0-9]+)?$"
+    },
+    "versions": {
+      "type": "string",
+      "pattern": "^[0-9]+(-[0-9]+|\\+)?$"
+    },
+    "flexibleVersions": {
+      "type": "string",
+      "pattern": "^(none|[0-9]+(-[0-9]+|\\+)?)$"
+    },
+    "type": {
+      "description": "Primitive type, struct name, or array of either prefixed by []",
+      "type": "string",
+      "pattern": "^(\\[\\])?(bool|int8|int16|int32|int64|float64|uuid|string|bytes|records|[A-Z][A-Za-z0-9]*)$"
+    },
+    "field": {
+      "type": "object",
+      "required": ["name", "type", "versions"],
+      "properties": {
+        "name": {
+          "type": "string",
+          "pattern": "^[A-Z][A-Za-z0-9]*$"
+        },
+        "type": { "$ref": "#/definitions/type" },
+        "versions": { "$ref": "#/definitions/versions" },
+        "nullableVersions": { "$ref": "#/definitions/versions" },
+        "taggedVersions": { "$ref": "#/definitions/versions" },
+        "flexibleVersions": { "$ref": "#/definitions/flexibleVersions" },
+        "tag": {
+          "type": "integer",
+          "minimum": 0
+        },
+        "default": {
+          "description": "Default value; numbers may be written as strings, including hex e.g. 0x7fffffff",
+          "type": ["string", "number", "boolean", "null"]
+        },
+        "entityType": {
+          "type": "string"
+        },
+        "ignorable": {
+          "type": "boolean"
+        },
+        "mapKey": {
+          "type": "boolean"
+        },
+        "about": {
+          "type": "string"
+        },
+        "fields": {
+          "type": "array",
+          "items": { "$ref": "#/definitions/field" }
+        }
+      }
+    },
+    "struct": {
+      "type": "object",
+      "required": ["name", "fields"],
+      "properties": {
+        "name": {
+          "type": "string",
+          "pattern": "^[A-Z][A-Za-z0-9]*$"
+        },
+        "versions": { "$ref": "#/definitions/versions" },
+        "fields": {
+          "type": "array",
+          "items": { "$ref": "#/definitions/field" }
+        }
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,115 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+)
+
+// TypeMapping overrides the go type generated for a primitive field.  Decode
+// and Encode are fmt style expressions that convert from and to the wire type
+// e.g. "time.Duration(%s) * time.Millisecond".  When omitted, a plain go
+// conversion is used.
+type TypeMapping struct {
+	Type   string `json:"type"`             // Type is the go type to generate
+	Import string `json:"import,omitempty"` // Import path required by Type, if any
+	Decode string `json:"decode,omitempty"` // Decode converts the wire value, %s, into Type
+	Encode string `json:"encode,omitempty"` // Encode converts the field value, %s, into the wire type
+}
+
+// DecodeExpr returns the expression that converts the wire value, v, into the mapped type
+func (t TypeMapping) DecodeExpr(v string) string {
+	if t.Decode == "" {
+		return t.Type + "(" + v + ")"
+	}
+	return fmt.Sprintf(t.Decode, v)
+}
+
+// EncodeExpr returns the expression that converts the field value, v, into wireType
+func (t TypeMapping) EncodeExpr(wireType, v string) string {
+	if t.Encode == "" {
+		return wireType + "(" + v + ")"
+	}
+	return fmt.Sprintf(t.Encode, v)
+}
+
+// TypeMappings holds the configured type overrides.  Fields are keyed by path,
+// Message.Field.SubField, and may contain path.Match wildcards e.g.
+// *.ErrorCode.  Lookups prefer an exact path, then a wildcard path, then the
+// entityType, and finally the primitive type.
+type TypeMappings struct {
+	Fields      map[string]TypeMapping `json:"fields,omitempty"`      // Fields keyed by path
+	EntityTypes map[string]TypeMapping `json:"entityTypes,omitempty"` // EntityTypes keyed by entityType e.g. topicName
+	Types       map[string]TypeMapping `json:"types,omitempty"`       // Types keyed by primitive type e.g. bytes
+}
+
+// LoadTypeMappings reads type mappings from a json document
+func LoadTypeMappings(r io.Reader) (TypeMappings, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return TypeMappings{}, fmt.Errorf("unable to read type mappings: %w", err)
+	}
+
+	var mappings TypeMappings
+	if err := json.Unmarshal(data, &mappings); err != nil {
+		return TypeMappings{}, fmt.Errorf("unable to parse type mappings: %w", err)
+	}
+
+	for key, m := range mappings.Fields {
+		if _, err := path.Match(key, ""); err != nil {
+			return TypeMappings{}, fmt.Errorf("invalid type mapping path, %v: %w", key, err)
+		}
+		if m.Type == "" {
+			return TypeMappings{}, fmt.Errorf("type mapping for path, %v, has no type", key)
+		}
+	}
+	for key, m := range mappings.EntityTypes {
+		if m.Type == "" {
+			return TypeMappings{}, fmt.Errorf("type mapping for entityType, %v, has no type", key)
+		}
+	}
+	for key, m := range mappings.Types {
+		if m.Type == "" {
+			return TypeMappings{}, fmt.Errorf("type mapping for type, %v, has no type", key)
+		}
+	}
+
+	return mappings, nil
+}
+
+// Lookup returns the mapping for the field found at the parent path.  Only
+// scalar, primitive fields may be mapped.
+func (t TypeMappings) Lookup(parent string, field Field) (TypeMapping, bool) {
+	if len(field.Fields) > 0 || strings.HasPrefix(field.Type, "[]") {
+		return TypeMapping{}, false
+	}
+
+	fieldPath := parent + "." + field.Name
+	if m, ok := t.Fields[fieldPath]; ok {
+		return m, true
+	}
+
+	patterns := make([]string, 0, len(t.Fields))
+	for pattern := range t.Fields {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, fieldPath); ok {
+			return t.Fields[pattern], true
+		}
+	}
+
+	if field.EntityType != "" {
+		if m, ok := t.EntityTypes[field.EntityType]; ok {
+			return m, true
+		}
+	}
+
+	m, ok := t.Types[field.Type]
+	return m, ok
+}
@@ -0,0 +1,125 @@
+package protocol
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadTypeMappings(t *testing.T) {
+	testCases := map[string]struct {
+		Data    string
+		WantErr bool
+	}{
+		"ok": {
+			Data: `{"fields": {"*.ErrorCode": {"type": "ErrorCode"}}}`,
+		},
+		"bad json": {
+			Data:    `{`,
+			WantErr: true,
+		},
+		"bad pattern": {
+			Data:    `{"fields": {"[": {"type": "ErrorCode"}}}`,
+			WantErr: true,
+		},
+		"missing type": {
+			Data:    `{"entityTypes": {"topicName": {}}}`,
+			WantErr: true,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			_, err := LoadTypeMappings(strings.NewReader(tc.Data))
+			if got := err != nil; got != tc.WantErr {
+				t.Fatalf("got %v; wantErr is %v", err, tc.WantErr)
+			}
+		})
+	}
+}
+
+func TestTypeMappings_Lookup(t *testing.T) {
+	mappings := TypeMappings{
+		Fields: map[string]TypeMapping{
+			"FetchResponse.ThrottleTimeMs": {Type: "time.Duration"},
+			"*.ErrorCode":                  {Type: "ErrorCode"},
+		},
+		EntityTypes: map[string]TypeMapping{
+			"topicName": {Type: "TopicName"},
+		},
+		Types: map[string]TypeMapping{
+			"bytes":  {Type: "RawBytes"},
+			"string": {Type: "Text"},
+		},
+	}
+
+	testCases := map[string]struct {
+		Path  string
+		Field Field
+		Want  string
+	}{
+		"exact path": {
+			Path:  "FetchResponse",
+			Field: Field{Name: "ThrottleTimeMs", Type: "int32"},
+			Want:  "time.Duration",
+		},
+		"wildcard path": {
+			Path:  "FetchResponse.Topics.Partitions",
+			Field: Field{Name: "ErrorCode", Type: "int16"},
+			Want:  "ErrorCode",
+		},
+		"entity type": {
+			Path:  "FetchRequest.Topics",
+			Field: Field{Name: "Name", Type: "string", EntityType: "topicName"},
+			Want:  "TopicName",
+		},
+		"type": {
+			Path:  "FetchResponse.Topics.Partitions",
+			Field: Field{Name: "Records", Type: "bytes"},
+			Want:  "RawBytes",
+		},
+		"array": {
+			Path:  "MetadataRequest",
+			Field: Field{Name: "Topics", Type: "[]string", EntityType: "topicName"},
+		},
+		"unmapped": {
+			Path:  "FetchRequest",
+			Field: Field{Name: "MaxWaitMs", Type: "int32"},
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			got, ok := mappings.Lookup(tc.Path, tc.Field)
+			if want := tc.Want != ""; ok != want {
+				t.Fatalf("got %v; want %v", ok, want)
+			}
+			if got.Type != tc.Want {
+				t.Fatalf("got %v; want %v", got.Type, tc.Want)
+			}
+		})
+	}
+}
+
+func TestTypeMapping_Expr(t *testing.T) {
+	var (
+		conversion = TypeMapping{Type: "ErrorCode"}
+		custom     = TypeMapping{
+			Type:   "time.Duration",
+			Decode: "time.Duration(%s) * time.Millisecond",
+			Encode: "int32(%s / time.Millisecond)",
+		}
+	)
+
+	if got, want := conversion.DecodeExpr("v"), "ErrorCode(v)"; got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+	if got, want := conversion.EncodeExpr("int16", "t.ErrorCode"), "int16(t.ErrorCode)"; got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+	if got, want := custom.DecodeExpr("v"), "time.Duration(v) * time.Millisecond"; got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+	if got, want := custom.EncodeExpr("int32", "t.ThrottleTimeMs"), "int32(t.ThrottleTimeMs / time.Millisecond)"; got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+}
@@ -14,12 +14,19 @@ var (
 
 // Field represents a single field (or struct) with the kafka message
 type Field struct {
-	Name     string          `json:"name,omitempty"`     // Name of field
-	Default  json.RawMessage `json:"default,omitempty"`  // Default value for field
-	Type     string          `json:"type,omitempty"`     // Type of field
-	Versions Versions        `json:"versions,omitempty"` // Versions field is compatible with
-	About    string          `json:"about"`              // About
-	Fields   []Field         `json:"fields,omitempty"`   // Fields for embedded type
+	Name             string          `json:"name,omitempty"`             // Name of field
+	Default          json.RawMessage `json:"default,omitempty"`          // Default value for field
+	Type             string          `json:"type,omitempty"`             // Type of field
+	Versions         Versions        `json:"versions,omitempty"`         // Versions field is compatible with
+	NullableVersions *Versions       `json:"nullableVersions,omitempty"` // NullableVersions field may be null, if any
+	TaggedVersions   *Versions       `json:"taggedVersions,omitempty"`   // TaggedVersions field is sent as a tagged field, if any
+	Tag              *int            `json:"tag,omitempty"`              // Tag of tagged field
+	FlexibleVersions string          `json:"flexibleVersions,omitempty"` // FlexibleVersions overrides the message flexible versions e.g. none
+	EntityType       string          `json:"entityType,omitempty"`       // EntityType e.g. topicName, groupId
+	Ignorable        bool            `json:"ignorable,omitempty"`        // Ignorable fields may be omitted when unsupported by the version
+	MapKey           bool            `json:"mapKey,omitempty"`           // MapKey marks the field as a key of its containing array
+	About            string          `json:"about"`                      // About
+	Fields           []Field         `json:"fields,omitempty"`           // Fields for embedded type
 }
 
 // Message definition for kafka protocol as defined here,
@@ -0,0 +1,239 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VersionRange holds an inclusive range of versions resolved against the
+// message's valid versions; a "1+" field of a "0-3" message becomes 1-3
+type VersionRange struct {
+	From int16 `json:"from"`
+	To   int16 `json:"to"`
+}
+
+// NormalizedField is the canonical form of Field
+type NormalizedField struct {
+	Name             string          `json:"name"`
+	Type             string          `json:"type"`
+	Struct           string          `json:"struct,omitempty"` // Struct names the element type of struct arrays
+	Array            bool            `json:"array,omitempty"`
+	Versions         *VersionRange   `json:"versions"` // Versions is nil if the field is unused by every valid version
+	NullableVersions *VersionRange   `json:"nullableVersions,omitempty"`
+	TaggedVersions   *VersionRange   `json:"taggedVersions,omitempty"`
+	Tag              *int            `json:"tag,omitempty"`
+	FlexibleVersions *VersionRange   `json:"flexibleVersions,omitempty"`
+	Default          json.RawMessage `json:"default,omitempty"`
+	EntityType       string          `json:"entityType,omitempty"`
+	Ignorable        bool            `json:"ignorable,omitempty"`
+	MapKey           bool            `json:"mapKey,omitempty"`
+	About            string          `json:"about,omitempty"`
+}
+
+// NormalizedStruct is a named struct referenced by a message's fields
+type NormalizedStruct struct {
+	Name   string            `json:"name"`
+	Common bool              `json:"common,omitempty"` // Common is true for commonStructs
+	Fields []NormalizedField `json:"fields"`
+}
+
+// NormalizedMessage is the canonical form of Message.  Nested structs are
+// flattened into Structs and referenced by name from NormalizedField.Struct.
+type NormalizedMessage struct {
+	ApiKey           int                `json:"apiKey"`
+	Type             string             `json:"type"`
+	Name             string             `json:"name"`
+	ValidVersions    VersionRange       `json:"validVersions"`
+	FlexibleVersions *VersionRange      `json:"flexibleVersions"` // FlexibleVersions is nil for none
+	Fields           []NormalizedField  `json:"fields"`
+	Structs          []NormalizedStruct `json:"structs,omitempty"`
+}
+
+// Schema holds the normalized form of a set of message definitions
+type Schema struct {
+	Messages []NormalizedMessage `json:"messages"`
+}
+
+// Normalize converts messages into their canonical form, sorted by api key
+// and name
+func Normalize(messages []Message) (Schema, error) {
+	var schema Schema
+	for _, message := range messages {
+		nm, err := normalizeMessage(message)
+		if err != nil {
+			return Schema{}, fmt.Errorf("unable to normalize message, %v: %w", message.Name, err)
+		}
+		schema.Messages = append(schema.Messages, nm)
+	}
+
+	sort.Slice(schema.Messages, func(i, j int) bool {
+		ii, jj := schema.Messages[i], schema.Messages[j]
+		if ii.ApiKey == jj.ApiKey {
+			return ii.Name < jj.Name
+		}
+		return ii.ApiKey < jj.ApiKey
+	})
+
+	return schema, nil
+}
+
+// MarshalIndent returns the canonical json encoding of the schema
+func (s Schema) MarshalIndent() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// resolve the versions against the valid versions of the message
+func resolve(v Versions, valid ValidVersions) *VersionRange {
+	r := VersionRange{From: v.From, To: v.To}
+	if v.UpToCurrent {
+		r.To = valid.To
+	}
+	if r.From < valid.From {
+		r.From = valid.From
+	}
+	if r.To > valid.To {
+		r.To = valid.To
+	}
+	if r.From > r.To {
+		return nil
+	}
+	return &r
+}
+
+// intersect returns the versions common to a and b; nil if there are none
+func intersect(a, b *VersionRange) *VersionRange {
+	if a == nil || b == nil {
+		return nil
+	}
+
+	r := *a
+	if r.From < b.From {
+		r.From = b.From
+	}
+	if r.To > b.To {
+		r.To = b.To
+	}
+	if r.From > r.To {
+		return nil
+	}
+	return &r
+}
+
+// ParseVersions parses a versions string such as "1+" or "none"; none returns nil
+func ParseVersions(s string) (*Versions, error) {
+	if s == "" || s == "none" {
+		return nil, nil
+	}
+
+	var v Versions
+	if err := json.Unmarshal([]byte(`"`+s+`"`), &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func normalizeMessage(message Message) (NormalizedMessage, error) {
+	valid := message.ValidVersions
+
+	nm := NormalizedMessage{
+		ApiKey:        message.ApiKey,
+		Type:          message.Type,
+		Name:          message.Name,
+		ValidVersions: VersionRange{From: valid.From, To: valid.To},
+	}
+
+	flexible, err := ParseVersions(message.FlexibleVersions)
+	if err != nil {
+		return NormalizedMessage{}, fmt.Errorf("invalid flexibleVersions, %v: %w", message.FlexibleVersions, err)
+	}
+	if flexible != nil {
+		nm.FlexibleVersions = resolve(*flexible, valid)
+	}
+
+	var normalizeFields func(fields []Field) ([]NormalizedField, error)
+	normalizeFields = func(fields []Field) ([]NormalizedField, error) {
+		normalized := make([]NormalizedField, 0, len(fields))
+		for _, f := range fields {
+			nf := NormalizedField{
+				Name:       f.Name,
+				Type:       f.Type,
+				Array:      strings.HasPrefix(f.Type, "[]"),
+				Versions:   resolve(f.Versions, valid),
+				Tag:        f.Tag,
+				EntityType: f.EntityType,
+				Ignorable:  f.Ignorable,
+				MapKey:     f.MapKey,
+				About:      f.About,
+			}
+			if len(f.Default) > 0 {
+				buf := bytes.NewBuffer(nil)
+				if err := json.Compact(buf, f.Default); err != nil {
+					return nil, fmt.Errorf("invalid default for field, %v: %w", f.Name, err)
+				}
+				nf.Default = buf.Bytes()
+			}
+			if f.NullableVersions != nil {
+				nf.NullableVersions = intersect(resolve(*f.NullableVersions, valid), nf.Versions)
+			}
+			if f.TaggedVersions != nil {
+				nf.TaggedVersions = intersect(resolve(*f.TaggedVersions, valid), nf.Versions)
+			}
+			if f.FlexibleVersions != "" {
+				v, err := ParseVersions(f.FlexibleVersions)
+				if err != nil {
+					return nil, fmt.Errorf("invalid flexibleVersions for field, %v: %w", f.Name, err)
+				}
+				if v != nil {
+					nf.FlexibleVersions = resolve(*v, valid)
+				}
+			}
+
+			if name := strings.TrimPrefix(f.Type, "[]"); isStruct(name) {
+				nf.Struct = name
+			}
+			if len(f.Fields) > 0 {
+				index := len(nm.Structs)
+				nm.Structs = append(nm.Structs, NormalizedStruct{Name: nf.Struct})
+				children, err := normalizeFields(f.Fields)
+				if err != nil {
+					return nil, err
+				}
+				nm.Structs[index].Fields = children
+			}
+
+			normalized = append(normalized, nf)
+		}
+		return normalized, nil
+	}
+
+	fields, err := normalizeFields(message.Fields)
+	if err != nil {
+		return NormalizedMessage{}, err
+	}
+	nm.Fields = fields
+
+	for _, cs := range message.CommonStructs {
+		index := len(nm.Structs)
+		nm.Structs = append(nm.Structs, NormalizedStruct{Name: cs.Name, Common: true})
+		children, err := normalizeFields(cs.Fields)
+		if err != nil {
+			return NormalizedMessage{}, err
+		}
+		nm.Structs[index].Fields = children
+	}
+
+	return nm, nil
+}
+
+// isStruct returns true if the type name refers to a struct rather than a
+// primitive; struct names are capitalized while primitives are lower case
+func isStruct(name string) bool {
+	return name != "" && name[0] >= 'A' && name[0] <= 'Z'
+}
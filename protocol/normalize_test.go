@@ -0,0 +1,197 @@
+package protocol
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	data := `{
+  "apiKey": 99,
+  "type": "response",
+  "name": "ExampleResponse",
+  "validVersions": "1-3",
+  "flexibleVersions": "3+",
+  "fields": [
+    { "name": "ErrorCode", "type": "int16", "versions": "0+", "default":   -1 },
+    { "name": "Topics", "type": "[]Topic", "versions": "2+", "nullableVersions": "3+", "fields": [
+      { "name": "Name", "type": "string", "versions": "0+", "mapKey": true, "entityType": "topicName" },
+      { "name": "Config", "type": "int16", "versions": "3+", "tag": 0, "taggedVersions": "0+" }
+    ]},
+    { "name": "TransactionalId", "type": "string", "versions": "2+", "nullableVersions": "0+" },
+    { "name": "Removed", "type": "int32", "versions": "0" },
+    { "name": "States", "type": "[]State", "versions": "0+" }
+  ],
+  "commonStructs": [
+    { "name": "State", "versions": "0+", "fields": [
+      { "name": "Epoch", "type": "int32", "versions": "0+" }
+    ]}
+  ]
+}`
+
+	message, err := Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	schema, err := Normalize([]Message{message})
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	tag := 0
+	want := NormalizedMessage{
+		ApiKey:           99,
+		Type:             "response",
+		Name:             "ExampleResponse",
+		ValidVersions:    VersionRange{From: 1, To: 3},
+		FlexibleVersions: &VersionRange{From: 3, To: 3},
+		Fields: []NormalizedField{
+			{Name: "ErrorCode", Type: "int16", Versions: &VersionRange{From: 1, To: 3}, Default: json.RawMessage(`-1`)},
+			{Name: "Topics", Type: "[]Topic", Struct: "Topic", Array: true, Versions: &VersionRange{From: 2, To: 3}, NullableVersions: &VersionRange{From: 3, To: 3}},
+			{Name: "TransactionalId", Type: "string", Versions: &VersionRange{From: 2, To: 3}, NullableVersions: &VersionRange{From: 2, To: 3}},
+			{Name: "Removed", Type: "int32"},
+			{Name: "States", Type: "[]State", Struct: "State", Array: true, Versions: &VersionRange{From: 1, To: 3}},
+		},
+		Structs: []NormalizedStruct{
+			{
+				Name: "Topic",
+				Fields: []NormalizedField{
+					{Name: "Name", Type: "string", Versions: &VersionRange{From: 1, To: 3}, EntityType: "topicName", MapKey: true},
+					{Name: "Config", Type: "int16", Versions: &VersionRange{From: 3, To: 3}, Tag: &tag, TaggedVersions: &VersionRange{From: 3, To: 3}},
+				},
+			},
+			{
+				Name:   "State",
+				Common: true,
+				Fields: []NormalizedField{
+					{Name: "Epoch", Type: "int32", Versions: &VersionRange{From: 1, To: 3}},
+				},
+			},
+		},
+	}
+
+	if got := schema.Messages; len(got) != 1 || !reflect.DeepEqual(got[0], want) {
+		t.Fatalf("got %#v; want %#v", got, want)
+	}
+}
+
+func TestNormalize_testdata(t *testing.T) {
+	messages, err := LoadSource("testdata")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	schema, err := Normalize(messages)
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	data, err := schema.MarshalIndent()
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	var decoded Schema
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if !reflect.DeepEqual(decoded, schema) {
+		t.Fatalf("got %#v; want %#v", decoded, schema)
+	}
+
+	// nullable versions are limited to the versions of the field
+	for _, m := range schema.Messages {
+		if m.Name != "ProduceRequest" {
+			continue
+		}
+		for _, f := range m.Fields {
+			if f.Name != "TransactionalId" {
+				continue
+			}
+			if got, want := f.NullableVersions, (&VersionRange{From: 3, To: 8}); !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %v; want %v", got, want)
+			}
+			return
+		}
+	}
+	t.Fatalf("got nil; want ProduceRequest.TransactionalId")
+}
+
+// TestDefinitionJSONSchema verifies the schema describes every key used by
+// the testdata definitions
+func TestDefinitionJSONSchema(t *testing.T) {
+	var schema struct {
+		Required    []string                   `json:"required"`
+		Properties  map[string]json.RawMessage `json:"properties"`
+		Definitions struct {
+			Field struct {
+				Required   []string                   `json:"required"`
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"field"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal([]byte(DefinitionJSONSchema), &schema); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	var checkFields func(filename string, fields []map[string]json.RawMessage)
+	checkFields = func(filename string, fields []map[string]json.RawMessage) {
+		for _, field := range fields {
+			for key := range field {
+				if _, ok := schema.Definitions.Field.Properties[key]; !ok && key != "fields" {
+					t.Fatalf("%v: field property, %v, not described by schema", filename, key)
+				}
+			}
+			if children, ok := field["fields"]; ok {
+				var nested []map[string]json.RawMessage
+				if err := json.Unmarshal(children, &nested); err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+				checkFields(filename, nested)
+			}
+		}
+	}
+
+	filenames, err := filepath.Glob("testdata/*.json")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	for _, filename := range filenames {
+		f, err := os.Open(filename)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		data, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		var message map[string]json.RawMessage
+		if err := json.Unmarshal(reComment.ReplaceAll(data, nil), &message); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		for _, key := range schema.Required {
+			if _, ok := message[key]; !ok {
+				t.Fatalf("%v: missing required property, %v", filename, key)
+			}
+		}
+		for key := range message {
+			if _, ok := schema.Properties[key]; !ok {
+				t.Fatalf("%v: property, %v, not described by schema", filename, key)
+			}
+		}
+
+		var fields []map[string]json.RawMessage
+		if err := json.Unmarshal(message["fields"], &fields); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		checkFields(filename, fields)
+	}
+}
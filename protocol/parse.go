@@ -6,10 +6,33 @@ import (
 	"io"
 	"io/ioutil"
 	"regexp"
+	"strings"
 )
 
 var reComment = regexp.MustCompile(`(?m)^\s*//.*$`)
 
+// primitives holds the primitive types that may be used by fields, either
+// directly or as the element type of an array
+var primitives = map[string]struct{}{
+	"bool":    {},
+	"bytes":   {},
+	"float64": {},
+	"int8":    {},
+	"int16":   {},
+	"int32":   {},
+	"int64":   {},
+	"records": {},
+	"string":  {},
+	"uuid":    {},
+}
+
+// IsPrimitive returns true if t, or the element type of t if t is an array,
+// is a primitive type rather than a struct
+func IsPrimitive(t string) bool {
+	_, ok := primitives[strings.TrimPrefix(t, "[]")]
+	return ok
+}
+
 // Parse message payload
 func Parse(r io.Reader) (Message, error) {
 	data, err := ioutil.ReadAll(r)
@@ -23,5 +46,29 @@ func Parse(r io.Reader) (Message, error) {
 		return Message{}, fmt.Errorf("unable to parse message: %w", err)
 	}
 
+	if err := validateTypes(message.Name, message.Fields); err != nil {
+		return Message{}, fmt.Errorf("unable to parse message: %w", err)
+	}
+	for _, cs := range message.CommonStructs {
+		if err := validateTypes(message.Name+"."+cs.Name, cs.Fields); err != nil {
+			return Message{}, fmt.Errorf("unable to parse message: %w", err)
+		}
+	}
+
 	return message, nil
 }
+
+// validateTypes ensures every field refers to either a struct or a known
+// primitive type
+func validateTypes(parent string, fields []Field) error {
+	for _, f := range fields {
+		path := parent + "." + f.Name
+		if f.Type == "[]records" || !isStruct(strings.TrimPrefix(f.Type, "[]")) && !IsPrimitive(f.Type) {
+			return fmt.Errorf("unknown type, %v, for field, %v", f.Type, path)
+		}
+		if err := validateTypes(path, f.Fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
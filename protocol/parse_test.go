@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io/ioutil"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -28,16 +29,18 @@ func TestParse(t *testing.T) {
 		FlexibleVersions: "none",
 		Fields: []Field{
 			{
-				Name:     "TransactionalId",
-				Type:     "string",
-				Versions: Versions{UpToCurrent: true},
-				About:    "The transactional id corresponding to the transaction.",
+				Name:       "TransactionalId",
+				Type:       "string",
+				Versions:   Versions{UpToCurrent: true},
+				EntityType: "transactionalId",
+				About:      "The transactional id corresponding to the transaction.",
 			},
 			{
-				Name:     "ProducerId",
-				Type:     "int64",
-				Versions: Versions{UpToCurrent: true},
-				About:    "Current producer id in use by the transactional id.",
+				Name:       "ProducerId",
+				Type:       "int64",
+				Versions:   Versions{UpToCurrent: true},
+				EntityType: "producerId",
+				About:      "Current producer id in use by the transactional id.",
 			},
 			{
 				Name:     "ProducerEpoch",
@@ -46,10 +49,11 @@ func TestParse(t *testing.T) {
 				About:    "Current epoch associated with the producer id.",
 			},
 			{
-				Name:     "GroupId",
-				Type:     "string",
-				Versions: Versions{UpToCurrent: true},
-				About:    "The unique group identifier.",
+				Name:       "GroupId",
+				Type:       "string",
+				Versions:   Versions{UpToCurrent: true},
+				EntityType: "groupId",
+				About:      "The unique group identifier.",
 			},
 		},
 	}
@@ -58,3 +62,68 @@ func TestParse(t *testing.T) {
 		t.Fatalf("got %#v; want %#v", got, want)
 	}
 }
+
+func TestParseUnknownType(t *testing.T) {
+	testCases := map[string]struct {
+		Type    string
+		WantErr bool
+	}{
+		"uuid": {
+			Type: "uuid",
+		},
+		"float64": {
+			Type: "float64",
+		},
+		"uuid array": {
+			Type: "[]uuid",
+		},
+		"struct": {
+			Type: "[]Topic",
+		},
+		"unknown": {
+			Type:    "uint128",
+			WantErr: true,
+		},
+		"unknown array": {
+			Type:    "[]float32",
+			WantErr: true,
+		},
+		"records array": {
+			Type:    "[]records",
+			WantErr: true,
+		},
+		"missing": {
+			Type:    "",
+			WantErr: true,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			data := `{
+  "apiKey": 99,
+  "type": "request",
+  "name": "ExampleRequest",
+  "validVersions": "0",
+  "fields": [
+    { "name": "Topics", "type": "[]Topic", "versions": "0+", "fields": [
+      { "name": "Value", "type": "` + tc.Type + `", "versions": "0+" }
+    ]}
+  ]
+}`
+			_, err := Parse(strings.NewReader(data))
+			if tc.WantErr {
+				if err == nil {
+					t.Fatalf("got nil; want err")
+				}
+				if got, want := err.Error(), "ExampleRequest.Topics.Value"; !strings.Contains(got, want) {
+					t.Fatalf("got %v; want %v", got, want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		})
+	}
+}
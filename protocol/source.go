@@ -0,0 +1,279 @@
+package protocol
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// checkoutDir holds the location of the message definitions within a kafka
+// source checkout or source release
+const checkoutDir = "clients/src/main/resources/common/message"
+
+// jarSeparator separates the name of a jar within an archive from the name of
+// a file within the jar, as in libs/kafka-clients-2.4.0.jar!/common/message
+const jarSeparator = "!/"
+
+// LoadSources reads the message definitions from each source in order.  A
+// definition in a later source replaces the definition with the same name
+// from an earlier source.
+func LoadSources(sources ...string) ([]Message, error) {
+	var (
+		byName = map[string]Message{}
+		names  []string
+	)
+
+	for _, src := range sources {
+		messages, err := LoadSource(src)
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range messages {
+			if _, ok := byName[message.Name]; !ok {
+				names = append(names, message.Name)
+			}
+			byName[message.Name] = message
+		}
+	}
+
+	messages := make([]Message, 0, len(names))
+	for _, name := range names {
+		messages = append(messages, byName[name])
+	}
+	return messages, nil
+}
+
+// LoadSource reads the message definitions from src which may be a directory
+// of json files, a kafka source checkout, or a .zip, .jar, .tar, .tar.gz, or
+// .tgz archive such as a kafka source release or a kafka binary release, whose
+// definitions are found within libs/kafka-clients-*.jar.  An error is returned
+// if src contains no definitions.
+func LoadSource(src string) ([]Message, error) {
+	messages, err := loadSource(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("unable to find message definitions in source, %v", src)
+	}
+	return messages, nil
+}
+
+func loadSource(src string) ([]Message, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read source, %v: %w", src, err)
+	}
+	if info.IsDir() {
+		return loadDir(src)
+	}
+
+	files, err := readArchive(src)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read archive, %v: %w", src, err)
+	}
+
+	var messages []Message
+	for _, name := range selectDefinitions(files) {
+		message, err := Parse(bytes.NewReader(files[name]))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse file, %v:%v: %w", src, name, err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func loadDir(dir string) ([]Message, error) {
+	if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(checkoutDir))); err == nil && info.IsDir() {
+		dir = filepath.Join(dir, filepath.FromSlash(checkoutDir))
+	}
+
+	var messages []Message
+	callback := func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("unable to open file, %v: %w", path, err)
+		}
+		defer f.Close()
+
+		message, err := Parse(f)
+		if err != nil {
+			return fmt.Errorf("unable to parse file, %v: %w", path, err)
+		}
+
+		messages = append(messages, message)
+		return nil
+	}
+
+	if err := filepath.Walk(dir, callback); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// readArchive returns the contents of every json file within the archive,
+// including those within the kafka-clients jar of a binary release
+func readArchive(filename string) (map[string][]byte, error) {
+	switch ext := strings.ToLower(filename); {
+	case strings.HasSuffix(ext, ".zip"), strings.HasSuffix(ext, ".jar"):
+		return readZip(filename)
+
+	case strings.HasSuffix(ext, ".tar"):
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return readTar(f)
+
+	case strings.HasSuffix(ext, ".tar.gz"), strings.HasSuffix(ext, ".tgz"):
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		return readTar(gz)
+
+	default:
+		return nil, fmt.Errorf("unsupported archive type")
+	}
+}
+
+func readZip(filename string) (map[string][]byte, error) {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return readZipFiles(&r.Reader)
+}
+
+func readZipFiles(r *zip.Reader) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[f.Name] = data
+	}
+	return files, nil
+}
+
+func readTar(r io.Reader) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		switch {
+		case strings.HasSuffix(hdr.Name, ".json"):
+			data, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			files[hdr.Name] = data
+
+		case isClientsJar(hdr.Name):
+			data, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				return nil, fmt.Errorf("unable to read jar, %v: %w", hdr.Name, err)
+			}
+			jar, err := readZipFiles(zr)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read jar, %v: %w", hdr.Name, err)
+			}
+			for name, data := range jar {
+				files[hdr.Name+jarSeparator+name] = data
+			}
+		}
+	}
+}
+
+// isClientsJar returns true if name holds the kafka-clients jar of a binary
+// release e.g. kafka_2.12-2.4.0/libs/kafka-clients-2.4.0.jar
+func isClientsJar(name string) bool {
+	dir, base := path.Split(name)
+	return path.Base(dir) == "libs" && strings.HasPrefix(base, "kafka-clients-") && strings.HasSuffix(base, ".jar")
+}
+
+// selectDefinitions returns the sorted names of the message definitions
+// within an archive.  Source releases contain json files other than message
+// definitions so definitions found under checkoutDir are preferred, followed
+// by those found under common/message as packaged in the kafka-clients jar,
+// either as the archive or within a binary release, and finally every json
+// file in the archive.
+func selectDefinitions(files map[string][]byte) []string {
+	filters := []func(name string) bool{
+		func(name string) bool { return strings.HasSuffix(path.Dir(name), checkoutDir) },
+		func(name string) bool {
+			dir := path.Dir(name)
+			return dir == "common/message" || strings.HasSuffix(dir, ".jar"+jarSeparator+"common/message")
+		},
+		func(name string) bool { return true },
+	}
+
+	for _, filter := range filters {
+		var names []string
+		for name := range files {
+			if filter(name) {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			sort.Strings(names)
+			return names
+		}
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package protocol
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testDefinition = "testdata/AddOffsetsToTxnRequest.json"
+	testOverride   = `{
+  "apiKey": 25,
+  "type": "request",
+  "name": "AddOffsetsToTxnRequest",
+  "validVersions": "0-2",
+  "flexibleVersions": "none",
+  "fields": []
+}`
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "source-")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, filename string, data []byte) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+}
+
+// testFiles contains a source release layout that includes json files which
+// are not message definitions
+func testFiles(t *testing.T) map[string][]byte {
+	data, err := ioutil.ReadFile(testDefinition)
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	return map[string][]byte{
+		"kafka-2.4.0-src/" + checkoutDir + "/AddOffsetsToTxnRequest.json":  data,
+		"kafka-2.4.0-src/clients/src/test/resources/common/message/X.json": []byte(`not json`),
+		"kafka-2.4.0-src/config/example.json":                              []byte(`not json`),
+	}
+}
+
+func writeTgz(t *testing.T, filename string, files map[string][]byte) {
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	w := tar.NewWriter(gz)
+	for name, data := range files {
+		hdr := &tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(data)),
+			Typeflag: tar.TypeReg,
+		}
+		if err := w.WriteHeader(hdr); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+}
+
+func assertLoaded(t *testing.T, messages []Message, wantVersions ValidVersions) {
+	if got, want := len(messages), 1; got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+	if got, want := messages[0].Name, "AddOffsetsToTxnRequest"; got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+	if got := messages[0].ValidVersions; got != wantVersions {
+		t.Fatalf("got %v; want %v", got, wantVersions)
+	}
+}
+
+func TestLoadSource(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	t.Run("checkout", func(t *testing.T) {
+		checkout := filepath.Join(dir, "checkout")
+		for name, data := range testFiles(t) {
+			writeFile(t, filepath.Join(checkout, filepath.FromSlash(strings.TrimPrefix(name, "kafka-2.4.0-src/"))), data)
+		}
+
+		messages, err := LoadSource(checkout)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		assertLoaded(t, messages, ValidVersions{To: 1})
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		filename := filepath.Join(dir, "kafka.zip")
+		f, err := os.Create(filename)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		w := zip.NewWriter(f)
+		for name, data := range testFiles(t) {
+			fw, err := w.Create(name)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if _, err := fw.Write(data); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		f.Close()
+
+		messages, err := LoadSource(filename)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		assertLoaded(t, messages, ValidVersions{To: 1})
+	})
+
+	t.Run("tgz", func(t *testing.T) {
+		filename := filepath.Join(dir, "kafka-2.4.0-src.tgz")
+		writeTgz(t, filename, testFiles(t))
+
+		messages, err := LoadSource(filename)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		assertLoaded(t, messages, ValidVersions{To: 1})
+	})
+
+	t.Run("binary release", func(t *testing.T) {
+		data, err := ioutil.ReadFile(testDefinition)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		buf := bytes.NewBuffer(nil)
+		w := zip.NewWriter(buf)
+		fw, err := w.Create("common/message/AddOffsetsToTxnRequest.json")
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if _, err := fw.Write(data); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		filename := filepath.Join(dir, "kafka_2.12-2.4.0.tgz")
+		writeTgz(t, filename, map[string][]byte{
+			"kafka_2.12-2.4.0/libs/kafka-clients-2.4.0.jar": buf.Bytes(),
+			"kafka_2.12-2.4.0/libs/other.jar":               []byte("not a jar"),
+		})
+
+		messages, err := LoadSource(filename)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		assertLoaded(t, messages, ValidVersions{To: 1})
+	})
+
+	t.Run("no definitions", func(t *testing.T) {
+		filename := filepath.Join(dir, "empty.tgz")
+		writeTgz(t, filename, map[string][]byte{"README": []byte("readme")})
+		if _, err := LoadSource(filename); err == nil {
+			t.Fatalf("got nil; want err")
+		}
+
+		empty := filepath.Join(dir, "empty")
+		if err := os.MkdirAll(empty, 0755); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if _, err := LoadSource(empty); err == nil {
+			t.Fatalf("got nil; want err")
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		filename := filepath.Join(dir, "kafka.rar")
+		writeFile(t, filename, []byte("rar"))
+
+		if _, err := LoadSource(filename); err == nil {
+			t.Fatalf("got nil; want err")
+		}
+	})
+}
+
+func TestLoadSources(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	override := filepath.Join(dir, "AddOffsetsToTxnRequest.json")
+	writeFile(t, override, []byte(testOverride))
+
+	messages, err := LoadSources("testdata", dir)
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	var found bool
+	for _, message := range messages {
+		if message.Name == "AddOffsetsToTxnRequest" {
+			found = true
+			if got, want := message.ValidVersions, (ValidVersions{To: 2}); got != want {
+				t.Fatalf("got %v; want %v", got, want)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("got false; want true")
+	}
+
+	all, err := LoadSource("testdata")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if got, want := len(messages), len(all); got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+}
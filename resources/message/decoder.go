@@ -19,11 +19,26 @@ package message
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"math"
 	"unicode/utf8"
+	"unsafe"
 )
 
+// DefaultMaxFrameSize holds the default limit on the size of frames read by
+// a stream decoder
+const DefaultMaxFrameSize = 100 << 20 // 100MB
+
+// minFrameBuffer holds the initial size of the buffer used by a stream decoder
+const minFrameBuffer = 4 << 10 // 4KB
+
 var (
+	errFrameTooLarge  = errors.New("frame too large")
+	errInvalidLength  = errors.New("invalid length")
+	errNotStream      = errors.New("decoder does not read from a stream")
+	errNullBytes      = errors.New("null bytes")
 	errNullString     = errors.New("null string")
 	errVarIntOverflow = errors.New("var int overflow")
 )
@@ -34,11 +49,35 @@ func IsInsufficientDataError(err error) bool {
 	return errors.Is(err, io.ErrShortBuffer)
 }
 
+// IsFrameTooLargeError if the stream contained a frame larger than the max
+// frame size of the decoder
+func IsFrameTooLargeError(err error) bool {
+	return errors.Is(err, errFrameTooLarge)
+}
+
+// IsInvalidLengthError if the input contained a negative length other than
+// the -1 used to indicate null
+func IsInvalidLengthError(err error) bool {
+	return errors.Is(err, errInvalidLength)
+}
+
 // Decoder implements a generic protocol decoder
 type Decoder struct {
 	raw    []byte
 	length int
 	offset int
+
+	limits    Limits
+	allocated int64 // allocated holds the bytes allocated since the last Reset or Next
+	depth     int   // depth of nested struct arrays
+
+	zeroCopy bool // zeroCopy returns byte slices that refer to the buffer rather than copies
+
+	// stream decoders only
+	r            io.Reader
+	frame        int    // frame holds the size of the current frame
+	frameBuf     *Frame // frameBuf holds the pooled buffer of the current frame in zero copy mode
+	maxFrameSize int
 }
 
 // NewDecoder returns a new Decoder
@@ -46,7 +85,89 @@ func NewDecoder(raw []byte, length int) *Decoder {
 	return &Decoder{
 		raw:    raw,
 		length: length,
+		limits: DefaultLimits,
+	}
+}
+
+// NewStreamDecoder returns a Decoder that reads size delimited frames, such
+// as kafka responses, from r.  Call Next to advance to each frame.  The
+// payload of a frame is read from r on demand as it is decoded, into a buffer
+// that grows as needed, up to maxFrameSize, and is reused by the next frame.
+// See SetZeroCopy for decoding without copying byte slices.
+func NewStreamDecoder(r io.Reader, maxFrameSize int) *Decoder {
+	if maxFrameSize <= 0 {
+		maxFrameSize = DefaultMaxFrameSize
+	}
+	return &Decoder{
+		r:            r,
+		maxFrameSize: maxFrameSize,
+		limits:       DefaultLimits,
+	}
+}
+
+// Next discards any unread portion of the current frame and reads the size
+// of the next frame from the stream.  Returns io.EOF if the stream ended
+// cleanly between frames.
+func (d *Decoder) Next() error {
+	if d.r == nil {
+		return errNotStream
+	}
+
+	if unread := d.frame - d.length; unread > 0 {
+		if _, err := io.CopyN(ioutil.Discard, d.r, int64(unread)); err != nil {
+			return unexpectedEOF(err)
+		}
+	}
+	d.frame, d.length, d.offset = 0, 0, 0
+	d.allocated, d.depth = 0, 0
+	if d.frameBuf != nil {
+		d.frameBuf.Release()
+		d.frameBuf = nil
+		d.raw = nil
+	}
+
+	var buf [4]byte
+	if _, err := io.ReadFull(d.r, buf[:]); err != nil {
+		return err
+	}
+
+	size := int(int32(binary.BigEndian.Uint32(buf[:])))
+	if size < 0 || size > d.maxFrameSize {
+		return fmt.Errorf("unable to read frame of %v bytes; max frame size is %v: %w", size, d.maxFrameSize, errFrameTooLarge)
+	}
+	d.frame = size
+
+	if d.zeroCopy {
+		// slices of a zero copy frame may outlive it so the frame is read
+		// into a buffer that is only reused once released.  The buffer grows
+		// as the frame is read rather than trusting the size prefix.
+		d.frameBuf = newFrame()
+		d.raw = d.frameBuf.data
+	}
+
+	return nil
+}
+
+// Peek returns the next n bytes without advancing the decoder.  The slice
+// refers to the buffer and is only valid until the decoder advances beyond
+// it, or, for stream decoders, reads more of the frame.
+func (d *Decoder) Peek(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, errInvalidLength
+	}
+	if err := d.remains(n); err != nil {
+		return nil, err
+	}
+	return d.raw[d.offset : d.offset+n : d.offset+n], nil
+}
+
+// Remaining returns the number of bytes in the current frame, or buffer,
+// that have not yet been decoded
+func (d *Decoder) Remaining() int {
+	if d.r != nil {
+		return d.frame - d.offset
 	}
+	return d.length - d.offset
 }
 
 // Reset allows the decoder to be reused by resetting the length of bytes available
@@ -54,22 +175,99 @@ func NewDecoder(raw []byte, length int) *Decoder {
 func (d *Decoder) Reset(length int) {
 	d.length = length
 	d.offset = 0
+	d.allocated = 0
+	d.depth = 0
 }
 
 // remains ensures that the buffer contains at least n more bytes
 func (d *Decoder) remains(n int) error {
 	if remain := d.length - d.offset; remain < n {
+		if d.r == nil {
+			return io.ErrShortBuffer
+		}
+		return d.fill(n)
+	}
+	return nil
+}
+
+// fill reads from the stream until at least n bytes beyond the offset have
+// been buffered.  Reads never extend beyond the end of the current frame.
+func (d *Decoder) fill(n int) error {
+	want := d.offset + n
+	if n < 0 || want > d.frame {
 		return io.ErrShortBuffer
 	}
+
+	if want > len(d.raw) {
+		size := 2 * len(d.raw)
+		if size < minFrameBuffer {
+			size = minFrameBuffer
+		}
+		if size < want {
+			size = want
+		}
+		if size > d.frame {
+			size = d.frame
+		}
+
+		// previously returned slices continue to refer to the old buffer
+		raw := make([]byte, size)
+		copy(raw, d.raw[:d.length])
+		d.raw = raw
+		if d.frameBuf != nil {
+			d.frameBuf.data = raw
+		}
+	}
+
+	end := len(d.raw)
+	if end > d.frame {
+		end = d.frame
+	}
+
+	read, err := io.ReadAtLeast(d.r, d.raw[d.length:end], want-d.length)
+	d.length += read
+	if err != nil {
+		return unexpectedEOF(err)
+	}
 	return nil
 }
 
-// ArrayLength reads the head of the buffer as an array length (int32)
+// varint returns the buffered bytes that may contain a var int
+func (d *Decoder) varint() ([]byte, error) {
+	if d.r != nil {
+		n := d.frame - d.offset
+		if n > binary.MaxVarintLen64 {
+			n = binary.MaxVarintLen64
+		}
+		if err := d.remains(n); err != nil {
+			return nil, err
+		}
+	}
+	if d.offset >= d.length {
+		return nil, io.ErrShortBuffer
+	}
+	return d.raw[d.offset:d.length], nil
+}
+
+// unexpectedEOF converts io.EOF, returned by a read that should have
+// succeeded, into io.ErrUnexpectedEOF
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// ArrayLength reads the head of the buffer as an array length (int32); -1
+// indicates a null array
 func (d *Decoder) ArrayLength() (int, error) {
 	n, err := d.Int32()
 	if err != nil {
 		return 0, err
 	}
+	if err := d.checkArrayLength(int(n)); err != nil {
+		return 0, err
+	}
 	return int(n), nil
 }
 
@@ -83,22 +281,157 @@ func (d *Decoder) Bool() (bool, error) {
 	return b, nil
 }
 
+// BoolArray returns the buffer head as an []bool
+func (d *Decoder) BoolArray() ([]bool, error) {
+	var items []bool
+	err := d.array(d.ArrayLength, 1, unsafe.Sizeof(items[0]), func(n int) { items = make([]bool, n) }, func(i int) (err error) {
+		items[i], err = d.Bool()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 // Bytes returns the buffer head as a byte array
 func (d *Decoder) Bytes() ([]byte, error) {
 	n, err := d.Int32()
 	if err != nil {
 		return nil, err
 	}
-	length := int(n)
 
-	if err := d.remains(length); err != nil {
+	if n == -1 {
+		return nil, nil
+	}
+
+	return d.bytes(int(n))
+}
+
+// BytesArray returns the buffer head as an [][]byte
+func (d *Decoder) BytesArray() ([][]byte, error) {
+	var items [][]byte
+	err := d.array(d.ArrayLength, 4, unsafe.Sizeof(items[0]), func(n int) { items = make([][]byte, n) }, func(i int) (err error) {
+		items[i], err = d.Bytes()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// CompactArrayLength reads the head of the buffer as a compact array length;
+// -1 indicates a null array
+func (d *Decoder) CompactArrayLength() (int, error) {
+	n, err := d.compactLength()
+	if err != nil {
+		return 0, err
+	}
+	if err := d.checkArrayLength(n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// CompactBytes returns the buffer head as a compact byte array
+func (d *Decoder) CompactBytes() ([]byte, error) {
+	n, err := d.compactLength()
+	if err != nil {
+		return nil, err
+	}
+	if n == -1 {
+		return nil, errNullBytes
+	}
+	return d.bytes(n)
+}
+
+// CompactInt32Array returns the buffer head as a compact []int32
+func (d *Decoder) CompactInt32Array() ([]int32, error) {
+	var items []int32
+	err := d.array(d.CompactArrayLength, 4, unsafe.Sizeof(items[0]), func(n int) { items = make([]int32, n) }, func(i int) (err error) {
+		items[i], err = d.Int32()
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
+	return items, nil
+}
 
-	a, b := d.offset, d.offset+length
-	v := d.raw[a:b:b] // limit capacity of returned slice
-	d.offset += length
-	return v, nil
+// CompactInt64Array returns the buffer head as a compact []int64
+func (d *Decoder) CompactInt64Array() ([]int64, error) {
+	var items []int64
+	err := d.array(d.CompactArrayLength, 8, unsafe.Sizeof(items[0]), func(n int) { items = make([]int64, n) }, func(i int) (err error) {
+		items[i], err = d.Int64()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// CompactNullableBytes returns the buffer head as a compact byte array; null
+// is returned as nil
+func (d *Decoder) CompactNullableBytes() ([]byte, error) {
+	n, err := d.compactLength()
+	if err != nil {
+		return nil, err
+	}
+	if n == -1 {
+		return nil, nil
+	}
+	return d.bytes(n)
+}
+
+// CompactNullableString returns the buffer head as a compact *string
+func (d *Decoder) CompactNullableString() (*string, error) {
+	n, err := d.compactLength()
+	if err != nil {
+		return nil, err
+	}
+	if n == -1 {
+		return nil, nil
+	}
+
+	data, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+
+	s := string(data)
+	return &s, nil
+}
+
+// CompactString returns the buffer head as a compact string
+func (d *Decoder) CompactString() (string, error) {
+	n, err := d.compactLength()
+	if err != nil {
+		return "", err
+	}
+	if n == -1 {
+		return "", errNullString
+	}
+
+	data, err := d.next(n)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// CompactStringArray returns the buffer head as a compact []string
+func (d *Decoder) CompactStringArray() ([]string, error) {
+	var items []string
+	err := d.array(d.CompactArrayLength, 1, unsafe.Sizeof(items[0]), func(n int) { items = make([]string, n) }, func(i int) (err error) {
+		items[i], err = d.CompactString()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 // Discard the specified number of bytes
@@ -112,6 +445,29 @@ func (d *Decoder) Discard(n int) error {
 	return nil
 }
 
+// Float64 returns the buffer head as a float64
+func (d *Decoder) Float64() (float64, error) {
+	if err := d.remains(8); err != nil {
+		return 0, err
+	}
+	v := math.Float64frombits(binary.BigEndian.Uint64(d.raw[d.offset:]))
+	d.offset += 8
+	return v, nil
+}
+
+// Float64Array returns the buffer head as an []float64
+func (d *Decoder) Float64Array() ([]float64, error) {
+	var items []float64
+	err := d.array(d.ArrayLength, 8, unsafe.Sizeof(items[0]), func(n int) { items = make([]float64, n) }, func(i int) (err error) {
+		items[i], err = d.Float64()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 // Int8 returns the buffer head as an int8
 func (d *Decoder) Int8() (int8, error) {
 	if err := d.remains(1); err != nil {
@@ -122,6 +478,19 @@ func (d *Decoder) Int8() (int8, error) {
 	return v, nil
 }
 
+// Int8Array returns the buffer head as an []int8
+func (d *Decoder) Int8Array() ([]int8, error) {
+	var items []int8
+	err := d.array(d.ArrayLength, 1, unsafe.Sizeof(items[0]), func(n int) { items = make([]int8, n) }, func(i int) (err error) {
+		items[i], err = d.Int8()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 // Int16 returns the buffer head as an int16
 func (d *Decoder) Int16() (int16, error) {
 	if err := d.remains(2); err != nil {
@@ -132,6 +501,19 @@ func (d *Decoder) Int16() (int16, error) {
 	return v, nil
 }
 
+// Int16Array returns the buffer head as an []int16
+func (d *Decoder) Int16Array() ([]int16, error) {
+	var items []int16
+	err := d.array(d.ArrayLength, 2, unsafe.Sizeof(items[0]), func(n int) { items = make([]int16, n) }, func(i int) (err error) {
+		items[i], err = d.Int16()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 // Int32 returns the buffer head as an int32
 func (d *Decoder) Int32() (int32, error) {
 	if err := d.remains(4); err != nil {
@@ -144,24 +526,14 @@ func (d *Decoder) Int32() (int32, error) {
 
 // Int32Array returns the buffer head as an []int32
 func (d *Decoder) Int32Array() ([]int32, error) {
-	n, err := d.ArrayLength()
+	var items []int32
+	err := d.array(d.ArrayLength, 4, unsafe.Sizeof(items[0]), func(n int) { items = make([]int32, n) }, func(i int) (err error) {
+		items[i], err = d.Int32()
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	if n == -1 {
-		return nil, nil
-	}
-
-	items := make([]int32, n)
-	for i := 0; i < n; i++ {
-		item, err := d.Int32()
-		if err != nil {
-			return nil, err
-		}
-		items[i] = item
-	}
-
 	return items, nil
 }
 
@@ -177,24 +549,14 @@ func (d *Decoder) Int64() (int64, error) {
 
 // Int64Array returns the buffer head as an []int64
 func (d *Decoder) Int64Array() ([]int64, error) {
-	n, err := d.ArrayLength()
+	var items []int64
+	err := d.array(d.ArrayLength, 8, unsafe.Sizeof(items[0]), func(n int) { items = make([]int64, n) }, func(i int) (err error) {
+		items[i], err = d.Int64()
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	if n == -1 {
-		return nil, nil
-	}
-
-	items := make([]int64, n)
-	for i := 0; i < n; i++ {
-		item, err := d.Int64()
-		if err != nil {
-			return nil, err
-		}
-		items[i] = item
-	}
-
 	return items, nil
 }
 
@@ -208,6 +570,19 @@ func (d *Decoder) NullableString() (*string, error) {
 	return &s, nil
 }
 
+// Records returns the buffer head as an unparsed record set; null is
+// returned as nil
+func (d *Decoder) Records() ([]byte, error) {
+	n, err := d.Int32()
+	if err != nil {
+		return nil, err
+	}
+	if n == -1 {
+		return nil, nil
+	}
+	return d.bytes(int(n))
+}
+
 // String returns the buffer head as a string
 func (d *Decoder) String() (string, error) {
 	n, err := d.Int16()
@@ -219,65 +594,103 @@ func (d *Decoder) String() (string, error) {
 		return "", nil
 	}
 
-	length := int(n)
-	if err := d.remains(length); err != nil {
+	data, err := d.next(int(n))
+	if err != nil {
 		return "", err
 	}
-
-	s := string(d.raw[d.offset : d.offset+length])
-	d.offset += length
-	return s, nil
+	return string(data), nil
 }
 
-// StringArray returns the buffer head as a []string
+// StringArray returns the buffer head as an []string
 func (d *Decoder) StringArray() ([]string, error) {
-	n, err := d.ArrayLength()
+	var items []string
+	err := d.array(d.ArrayLength, 2, unsafe.Sizeof(items[0]), func(n int) { items = make([]string, n) }, func(i int) (err error) {
+		items[i], err = d.String()
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return items, nil
+}
 
-	if n == -1 {
-		return nil, nil
+// Uuid returns the buffer head as a UUID
+func (d *Decoder) Uuid() (UUID, error) {
+	var u UUID
+	if err := d.remains(len(u)); err != nil {
+		return UUID{}, err
 	}
+	copy(u[:], d.raw[d.offset:])
+	d.offset += len(u)
+	return u, nil
+}
 
-	items := make([]string, n)
-	for i := 0; i < n; i++ {
-		item, err := d.String()
-		if err != nil {
-			return nil, err
-		}
-		items[i] = item
+// UuidArray returns the buffer head as an []UUID
+func (d *Decoder) UuidArray() ([]UUID, error) {
+	var items []UUID
+	err := d.array(d.ArrayLength, 16, unsafe.Sizeof(items[0]), func(n int) { items = make([]UUID, n) }, func(i int) (err error) {
+		items[i], err = d.Uuid()
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return items, nil
 }
 
+// Uvarint returns the buffer head as an unsigned var int
+func (d *Decoder) Uvarint() (uint64, error) {
+	data, err := d.varint()
+	if err != nil {
+		return 0, err
+	}
+
+	tmp, n := binary.Uvarint(data)
+	switch {
+	case n == 0:
+		d.offset = d.length // no further requests can be made
+		return 0, io.ErrShortBuffer
+
+	case n < 0:
+		d.offset = d.length // no further requests can be made
+		return 0, errVarIntOverflow
+
+	default:
+		d.offset += n
+		return tmp, nil
+	}
+}
+
 func (d *Decoder) VarBytes() ([]byte, error) {
 	n, err := d.VarInt()
 	if err != nil {
 		return nil, err
 	}
-	length := int(n)
 
-	if err := d.remains(length); err != nil {
-		return nil, err
+	if n == -1 {
+		return nil, nil
+	}
+	if n < -1 || n > math.MaxInt32 {
+		return nil, errInvalidLength
 	}
 
-	a, b := d.offset, d.offset+length
-	v := d.raw[a:b:b] // limit capacity of returned slice
-	d.offset += length
-	return v, nil
+	return d.bytes(int(n))
 }
 
 // VarInt returns the buffer head as an int64
 func (d *Decoder) VarInt() (int64, error) {
-	tmp, n := binary.Varint(d.raw[d.offset:])
-	switch n {
-	case 0:
+	data, err := d.varint()
+	if err != nil {
+		return 0, err
+	}
+
+	tmp, n := binary.Varint(data)
+	switch {
+	case n == 0:
 		d.offset = d.length // no further requests can be made
 		return 0, io.ErrShortBuffer
 
-	case -1:
+	case n < 0:
 		d.offset = d.length // no further requests can be made
 		return 0, errVarIntOverflow
 
@@ -287,29 +700,117 @@ func (d *Decoder) VarInt() (int64, error) {
 	}
 }
 
+// VarLong returns the buffer head as a var long as used by record batches
+func (d *Decoder) VarLong() (int64, error) {
+	return d.VarInt()
+}
+
 func (d *Decoder) VarString() (string, error) {
 	n, err := d.VarInt()
 	if err != nil {
 		return "", err
 	}
-	length := int(n)
 
-	if err := d.remains(length); err != nil {
+	if n == -1 {
+		return "", nil
+	}
+	if n < -1 || n > math.MaxInt32 {
+		return "", errInvalidLength
+	}
+
+	data, err := d.next(int(n))
+	if err != nil {
 		return "", err
 	}
 
 	var (
-		eom   = d.offset + length // end of message
-		som   = d.offset          // start of message
+		eom   = len(data) // end of message
+		som   = 0         // start of message
 		runes []rune
 	)
 
 	for som < eom {
-		r, size := utf8.DecodeRune(d.raw[som:eom])
+		r, size := utf8.DecodeRune(data[som:eom])
 		som += size
 		runes = append(runes, r)
 	}
 
-	d.offset += length
 	return string(runes), nil
 }
+
+// compactLength reads an unsigned varint length encoded as n+1; -1 indicates
+// null
+func (d *Decoder) compactLength() (int, error) {
+	u, err := d.Uvarint()
+	if err != nil {
+		return 0, err
+	}
+	if u > math.MaxInt32 {
+		return 0, errVarIntOverflow
+	}
+	return int(u) - 1, nil
+}
+
+// bytes returns the next n bytes of the buffer; a copy unless the decoder is
+// in zero copy mode
+func (d *Decoder) bytes(n int) ([]byte, error) {
+	data, err := d.next(n)
+	if err != nil || d.zeroCopy {
+		return data, err
+	}
+
+	v := make([]byte, len(data))
+	copy(v, data)
+	return v, nil
+}
+
+// next returns the next n bytes of the buffer
+func (d *Decoder) next(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, errInvalidLength
+	}
+	if max := d.limits.MaxBytesLength; max > 0 && n > max {
+		return nil, &LimitError{Limit: "MaxBytesLength", Value: n, Max: max}
+	}
+	if err := d.remains(n); err != nil {
+		return nil, err
+	}
+	if err := d.allocate(n, 1); err != nil {
+		return nil, err
+	}
+
+	a, b := d.offset, d.offset+n
+	v := d.raw[a:b:b] // limit capacity of returned slice
+	d.offset += n
+	return v, nil
+}
+
+// array reads the array length, using readLength, and then decodes each
+// element; alloc is invoked with the length unless the array is null.  size
+// holds the minimum encoded size of an element and elem the size of the go
+// element type, which is accounted against MaxAllocation.
+func (d *Decoder) array(readLength func() (int, error), size int, elem uintptr, alloc func(n int), decode func(i int) error) error {
+	n, err := readLength()
+	if err != nil {
+		return err
+	}
+
+	if n == -1 {
+		return nil
+	}
+	if err := d.checkRemaining(n, size); err != nil {
+		return err
+	}
+	if err := d.allocate(n, elem); err != nil {
+		return err
+	}
+
+	alloc(n)
+	for i := 0; i < n; i++ {
+		if err := decode(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"math"
 	"reflect"
 	"testing"
+	"testing/iotest"
 )
 
 func makeTestDecoder(data []byte) *Decoder {
@@ -673,6 +674,46 @@ func TestDecoder_remain(t *testing.T) {
 		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
 	}
 
+	_, err = d.CompactArrayLength()
+	if !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+
+	_, err = d.CompactBytes()
+	if !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+
+	_, err = d.CompactInt32Array()
+	if !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+
+	_, err = d.CompactInt64Array()
+	if !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+
+	_, err = d.CompactNullableBytes()
+	if !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+
+	_, err = d.CompactNullableString()
+	if !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+
+	_, err = d.CompactString()
+	if !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+
+	_, err = d.CompactStringArray()
+	if !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+
 	err = d.Discard(100)
 	if !IsInsufficientDataError(err) {
 		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
@@ -723,6 +764,11 @@ func TestDecoder_remain(t *testing.T) {
 		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
 	}
 
+	_, err = d.Uvarint()
+	if !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+
 	_, err = d.VarBytes()
 	if !IsInsufficientDataError(err) {
 		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
@@ -733,6 +779,26 @@ func TestDecoder_remain(t *testing.T) {
 		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
 	}
 
+	_, err = d.Float64()
+	if !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+
+	_, err = d.Records()
+	if !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+
+	_, err = d.Uuid()
+	if !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+
+	_, err = d.VarLong()
+	if !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+
 	_, err = d.VarString()
 	if !IsInsufficientDataError(err) {
 		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
@@ -755,6 +821,28 @@ func TestDecoder_Discard(t *testing.T) {
 	}
 }
 
+func TestDecoder_Peek(t *testing.T) {
+	decoder := NewDecoder([]byte{1, 2, 3}, 3)
+
+	data, err := decoder.Peek(2)
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if got, want := data, []byte{1, 2}; !bytes.Equal(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+	if got, want := decoder.Remaining(), 3; got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+
+	if _, err := decoder.Peek(4); !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+	if _, err := decoder.Peek(-1); !IsInvalidLengthError(err) {
+		t.Fatalf("got %v; want %v", err, errInvalidLength)
+	}
+}
+
 func TestDecoder_Reset(t *testing.T) {
 	decoder := &Decoder{}
 	decoder.offset = 10
@@ -769,3 +857,646 @@ func TestDecoder_Reset(t *testing.T) {
 		t.Fatalf("got %v; want %v", got, want)
 	}
 }
+
+func TestDecoder_PutUvarint(t *testing.T) {
+	testCases := map[string]struct {
+		want uint64
+	}{
+		"0": {
+			want: 0,
+		},
+		"127": {
+			want: 127,
+		},
+		"128": {
+			want: 128,
+		},
+		"uint64": {
+			want: math.MaxUint64,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			e := &Encoder{target: buf}
+			e.PutUvarint(tc.want)
+			if err := e.Flush(); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+
+			decoder := makeTestDecoder(buf.Bytes())
+			got, err := decoder.Uvarint()
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if got != tc.want {
+				t.Fatalf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_PutVarLong(t *testing.T) {
+	for _, want := range []int64{0, -1, math.MinInt64, math.MaxInt64} {
+		buf := bytes.NewBuffer(nil)
+		e := &Encoder{target: buf}
+		e.PutVarLong(want)
+		if err := e.Flush(); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		got, err := makeTestDecoder(buf.Bytes()).VarLong()
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if got != want {
+			t.Fatalf("got %v; want %v", got, want)
+		}
+	}
+}
+
+func TestDecoder_PutCompactBytes(t *testing.T) {
+	testCases := map[string]struct {
+		want []byte
+	}{
+		"none": {
+			want: []byte{},
+		},
+		"some": {
+			want: []byte("hello world"),
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			e := &Encoder{target: buf}
+			e.PutCompactBytes(tc.want)
+			if err := e.Flush(); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+
+			got, err := makeTestDecoder(buf.Bytes()).CompactBytes()
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if !bytes.Equal(got, tc.want) {
+				t.Fatalf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_PutCompactNullableBytes(t *testing.T) {
+	testCases := map[string]struct {
+		want []byte
+	}{
+		"nil": {
+			want: nil,
+		},
+		"none": {
+			want: []byte{},
+		},
+		"some": {
+			want: []byte("hello world"),
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			e := &Encoder{target: buf}
+			e.PutCompactNullableBytes(tc.want)
+			if err := e.Flush(); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+
+			got, err := makeTestDecoder(buf.Bytes()).CompactNullableBytes()
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %#v; want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_PutCompactString(t *testing.T) {
+	testCases := map[string]struct {
+		want string
+	}{
+		"blank": {
+			want: "",
+		},
+		"some": {
+			want: "hello world",
+		},
+		"unicode": {
+			want: "你好",
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			e := &Encoder{target: buf}
+			e.PutCompactString(tc.want)
+			if err := e.Flush(); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+
+			got, err := makeTestDecoder(buf.Bytes()).CompactString()
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if got != tc.want {
+				t.Fatalf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_PutCompactNullableString(t *testing.T) {
+	var (
+		blank = ""
+		some  = "some"
+	)
+
+	testCases := map[string]struct {
+		want *string
+	}{
+		"nil": {
+			want: nil,
+		},
+		"blank": {
+			want: &blank,
+		},
+		"some": {
+			want: &some,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			e := &Encoder{target: buf}
+			e.PutCompactNullableString(tc.want)
+			if err := e.Flush(); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+
+			got, err := makeTestDecoder(buf.Bytes()).CompactNullableString()
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_PutCompactArrays(t *testing.T) {
+	testCases := map[string]struct {
+		int32s  []int32
+		int64s  []int64
+		strings []string
+	}{
+		"nil": {},
+		"empty": {
+			int32s:  []int32{},
+			int64s:  []int64{},
+			strings: []string{},
+		},
+		"some": {
+			int32s:  []int32{1, 2, 3},
+			int64s:  []int64{math.MinInt64, math.MaxInt64},
+			strings: []string{"a", "b", "c"},
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			e := &Encoder{target: buf}
+			e.PutCompactInt32Array(tc.int32s)
+			e.PutCompactInt64Array(tc.int64s)
+			e.PutCompactStringArray(tc.strings)
+			if err := e.Flush(); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+
+			decoder := makeTestDecoder(buf.Bytes())
+			int32s, err := decoder.CompactInt32Array()
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if !reflect.DeepEqual(int32s, tc.int32s) {
+				t.Fatalf("got %#v; want %#v", int32s, tc.int32s)
+			}
+
+			int64s, err := decoder.CompactInt64Array()
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if !reflect.DeepEqual(int64s, tc.int64s) {
+				t.Fatalf("got %#v; want %#v", int64s, tc.int64s)
+			}
+
+			strings, err := decoder.CompactStringArray()
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if !reflect.DeepEqual(strings, tc.strings) {
+				t.Fatalf("got %#v; want %#v", strings, tc.strings)
+			}
+		})
+	}
+}
+
+func TestDecoder_compactOverflow(t *testing.T) {
+	testCases := map[string]struct {
+		data    []byte
+		decode  func(d *Decoder) error
+		wantErr error
+	}{
+		"uvarint longer than 64 bits": {
+			data: bytes.Repeat([]byte{0xff}, 11),
+			decode: func(d *Decoder) error {
+				_, err := d.Uvarint()
+				return err
+			},
+			wantErr: errVarIntOverflow,
+		},
+		"length larger than int32": {
+			data: []byte{0xff, 0xff, 0xff, 0xff, 0x0f},
+			decode: func(d *Decoder) error {
+				_, err := d.CompactArrayLength()
+				return err
+			},
+			wantErr: errVarIntOverflow,
+		},
+		"null string": {
+			data: []byte{0},
+			decode: func(d *Decoder) error {
+				_, err := d.CompactString()
+				return err
+			},
+			wantErr: errNullString,
+		},
+		"null bytes": {
+			data: []byte{0},
+			decode: func(d *Decoder) error {
+				_, err := d.CompactBytes()
+				return err
+			},
+			wantErr: errNullBytes,
+		},
+		"truncated": {
+			data: []byte{0x80},
+			decode: func(d *Decoder) error {
+				_, err := d.Uvarint()
+				return err
+			},
+			wantErr: io.ErrShortBuffer,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			if got := tc.decode(makeTestDecoder(tc.data)); got != tc.wantErr {
+				t.Fatalf("got %v; want %v", got, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecoder_PutFloat64(t *testing.T) {
+	for _, want := range []float64{0, -1.5, math.MaxFloat64, math.SmallestNonzeroFloat64, math.Inf(1)} {
+		buf := bytes.NewBuffer(nil)
+		e := &Encoder{target: buf}
+		e.PutFloat64(want)
+		if err := e.Flush(); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		got, err := makeTestDecoder(buf.Bytes()).Float64()
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if got != want {
+			t.Fatalf("got %v; want %v", got, want)
+		}
+	}
+}
+
+func TestDecoder_PutUuid(t *testing.T) {
+	want := UUID{0x30, 0xad, 0xa7, 0x30, 0xb9, 0xe1, 0x4f, 0xe5, 0x83, 0x8b, 0xc0, 0x99, 0xe2, 0x56, 0xcf, 0x09}
+
+	buf := bytes.NewBuffer(nil)
+	e := &Encoder{target: buf}
+	e.PutUuid(want)
+	if err := e.Flush(); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if got, want := buf.Len(), 16; got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+
+	got, err := makeTestDecoder(buf.Bytes()).Uuid()
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+}
+
+func TestDecoder_PutRecords(t *testing.T) {
+	testCases := map[string]struct {
+		want []byte
+	}{
+		"nil": {
+			want: nil,
+		},
+		"some": {
+			want: []byte("hello world"),
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			e := &Encoder{target: buf}
+			e.PutRecords(tc.want)
+			if err := e.Flush(); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+
+			got, err := makeTestDecoder(buf.Bytes()).Records()
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %#v; want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_PutPrimitiveArrays(t *testing.T) {
+	testCases := map[string]struct {
+		encode func(e *Encoder)
+		decode func(d *Decoder) (interface{}, error)
+		want   interface{}
+	}{
+		"bool": {
+			encode: func(e *Encoder) { e.PutBoolArray([]bool{true, false}) },
+			decode: func(d *Decoder) (interface{}, error) { return d.BoolArray() },
+			want:   []bool{true, false},
+		},
+		"bytes": {
+			encode: func(e *Encoder) { e.PutBytesArray([][]byte{[]byte("a"), {}}) },
+			decode: func(d *Decoder) (interface{}, error) { return d.BytesArray() },
+			want:   [][]byte{[]byte("a"), {}},
+		},
+		"float64": {
+			encode: func(e *Encoder) { e.PutFloat64Array([]float64{1.5, -2}) },
+			decode: func(d *Decoder) (interface{}, error) { return d.Float64Array() },
+			want:   []float64{1.5, -2},
+		},
+		"int8": {
+			encode: func(e *Encoder) { e.PutInt8Array([]int8{math.MinInt8, math.MaxInt8}) },
+			decode: func(d *Decoder) (interface{}, error) { return d.Int8Array() },
+			want:   []int8{math.MinInt8, math.MaxInt8},
+		},
+		"int16": {
+			encode: func(e *Encoder) { e.PutInt16Array([]int16{math.MinInt16, math.MaxInt16}) },
+			decode: func(d *Decoder) (interface{}, error) { return d.Int16Array() },
+			want:   []int16{math.MinInt16, math.MaxInt16},
+		},
+		"int16 nil": {
+			encode: func(e *Encoder) { e.PutInt16Array(nil) },
+			decode: func(d *Decoder) (interface{}, error) { return d.Int16Array() },
+			want:   []int16(nil),
+		},
+		"uuid": {
+			encode: func(e *Encoder) { e.PutUuidArray([]UUID{UUID{1}, UUID{2}}) },
+			decode: func(d *Decoder) (interface{}, error) { return d.UuidArray() },
+			want:   []UUID{UUID{1}, UUID{2}},
+		},
+		"uuid empty": {
+			encode: func(e *Encoder) { e.PutUuidArray([]UUID{}) },
+			decode: func(d *Decoder) (interface{}, error) { return d.UuidArray() },
+			want:   []UUID{},
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			e := &Encoder{target: buf}
+			tc.encode(e)
+			if err := e.Flush(); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+
+			got, err := tc.decode(makeTestDecoder(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %#v; want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_arrayShortBuffer(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	e := &Encoder{target: buf}
+	e.PutInt16Array([]int16{1, 2, 3})
+	if err := e.Flush(); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	data := buf.Bytes()
+	got, err := makeTestDecoder(data[:len(data)-1]).Int16Array()
+	if !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+	if got != nil {
+		t.Fatalf("got %v; want nil", got)
+	}
+}
+
+func makeTestFrames(t *testing.T, frames ...func(e *Encoder)) []byte {
+	var stream []byte
+	for _, fn := range frames {
+		buf := bytes.NewBuffer(nil)
+		e := &Encoder{target: buf}
+		fn(e)
+		if err := e.Flush(); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		size := bytes.NewBuffer(nil)
+		e = &Encoder{target: size}
+		e.PutInt32(int32(buf.Len()))
+		stream = append(stream, size.Bytes()...)
+		stream = append(stream, buf.Bytes()...)
+	}
+	return stream
+}
+
+func TestStreamDecoder(t *testing.T) {
+	large := bytes.Repeat([]byte("a"), 3*minFrameBuffer)
+	stream := makeTestFrames(t,
+		func(e *Encoder) {
+			e.PutString("hello")
+			e.PutVarInt(-123)
+		},
+		func(e *Encoder) {
+			e.PutInt32(1)
+			e.PutBytes(large)
+		},
+		func(e *Encoder) {
+			e.PutUvarint(300)
+		},
+	)
+
+	d := NewStreamDecoder(iotest.OneByteReader(bytes.NewReader(stream)), 0)
+
+	if err := d.Next(); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if s, err := d.String(); err != nil || s != "hello" {
+		t.Fatalf("got %v, %v; want hello, nil", s, err)
+	}
+	if v, err := d.VarInt(); err != nil || v != -123 {
+		t.Fatalf("got %v, %v; want -123, nil", v, err)
+	}
+	if got, want := d.Remaining(), 0; got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+	if _, err := d.Int8(); !IsInsufficientDataError(err) {
+		t.Fatalf("got %v; want %v", err, io.ErrShortBuffer)
+	}
+
+	// decode only part of the frame; Next discards the remainder
+	if err := d.Next(); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if v, err := d.Int32(); err != nil || v != 1 {
+		t.Fatalf("got %v, %v; want 1, nil", v, err)
+	}
+	if got, limit := d.length, minFrameBuffer; got > limit {
+		t.Fatalf("got %v; want no more than %v bytes buffered", got, limit)
+	}
+
+	if err := d.Next(); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if v, err := d.Uvarint(); err != nil || v != 300 {
+		t.Fatalf("got %v, %v; want 300, nil", v, err)
+	}
+
+	if err := d.Next(); err != io.EOF {
+		t.Fatalf("got %v; want %v", err, io.EOF)
+	}
+}
+
+func TestStreamDecoder_grow(t *testing.T) {
+	want := bytes.Repeat([]byte("a"), 3*minFrameBuffer)
+	stream := makeTestFrames(t, func(e *Encoder) { e.PutBytes(want) })
+
+	d := NewStreamDecoder(bytes.NewReader(stream), 0)
+	if err := d.Next(); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	got, err := d.Bytes()
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got %v bytes; want %v bytes", len(got), len(want))
+	}
+	if got, want := len(d.raw), d.frame; got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+}
+
+func TestStreamDecoder_errors(t *testing.T) {
+	frame := makeTestFrames(t, func(e *Encoder) { e.PutInt64(1) })
+
+	t.Run("too large", func(t *testing.T) {
+		d := NewStreamDecoder(bytes.NewReader(frame), 4)
+		if err := d.Next(); !IsFrameTooLargeError(err) {
+			t.Fatalf("got %v; want %v", err, errFrameTooLarge)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		d := NewStreamDecoder(bytes.NewReader(frame[:len(frame)-1]), 0)
+		if err := d.Next(); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if _, err := d.Int64(); err != io.ErrUnexpectedEOF {
+			t.Fatalf("got %v; want %v", err, io.ErrUnexpectedEOF)
+		}
+	})
+
+	t.Run("not a stream", func(t *testing.T) {
+		if err := makeTestDecoder(frame).Next(); err != errNotStream {
+			t.Fatalf("got %v; want %v", err, errNotStream)
+		}
+	})
+}
+
+// testDecodeReuse decodes a large message followed by a small message into a
+// populated value and verifies, for each version, that the result matches a
+// fresh decode of the small one
+func testDecodeReuse(t *testing.T, newMessage func() testMessage, from, to int16) {
+	for version := from; version <= to; version++ {
+		encode := func(n int) []byte {
+			m := newMessage()
+			if n > 0 {
+				fill(reflect.ValueOf(m).Elem(), fillPopulate, n, nil)
+			}
+			e := NewAppendEncoder(nil)
+			m.Encode(e, version)
+			return e.Bytes()
+		}
+		decode := func(m testMessage, data []byte) {
+			if err := m.Decode(NewDecoder(data, len(data)), version); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		}
+
+		large, small := encode(4), encode(0)
+
+		got := newMessage()
+		fill(reflect.ValueOf(got).Elem(), fillPopulate, 2, nil)
+		decode(got, large)
+		decode(got, small)
+
+		want := newMessage()
+		decode(want, small)
+
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("version %v: got %#v; want %#v", version, got, want)
+		}
+
+		// a reset message encodes as an empty one, nullable fields included
+		decode(got, large)
+		got.Reset()
+		e := NewAppendEncoder(nil)
+		got.Encode(e, version)
+		if got, want := e.Bytes(), small; !bytes.Equal(got, want) {
+			t.Fatalf("version %v: got %x; want %x", version, got, want)
+		}
+	}
+}
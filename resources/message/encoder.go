@@ -18,18 +18,37 @@ package message
 
 import (
 	"encoding/binary"
+	"errors"
+	"hash/crc32"
 	"io"
-	"unicode/utf8"
+	"math"
 )
 
+var (
+	errLengthOverflow = errors.New("length overflow")
+	errNotAppending   = errors.New("encoder does not support back-patching")
+	errPatchOffset    = errors.New("patch offset out of range")
+)
+
+// castagnoli holds the crc32c table used to checksum record batches
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// CRC32C returns the crc32c checksum, as used by record batches, of data
+func CRC32C(data []byte) uint32 {
+	return crc32.Checksum(data, castagnoli)
+}
+
 type flusher interface {
 	Flush() error
 }
 
-// encoder provides protocol primitive encoders
+// encoder provides protocol primitive encoders.  An encoder without a target
+// appends to an in memory buffer which may be back-patched; the zero value is
+// an empty append encoder.
 type Encoder struct {
 	buf    [16]byte
 	target io.Writer
+	out    []byte // out holds the encoded bytes when target is nil
 	err    error
 }
 
@@ -40,6 +59,97 @@ func NewEncoder(target io.Writer) *Encoder {
 	}
 }
 
+// NewAppendEncoder returns an encoder that appends to buf.  Pass a buf whose
+// capacity is the Size of the message to encode without reallocating e.g.
+//
+//	e := NewAppendEncoder(make([]byte, 0, int(req.Size(version))))
+func NewAppendEncoder(buf []byte) *Encoder {
+	return &Encoder{
+		out: buf,
+	}
+}
+
+// Bytes returns the bytes encoded by an append encoder.  The slice is only
+// valid until the next call to a Put method or Reset.
+func (e *Encoder) Bytes() []byte {
+	return e.out
+}
+
+// Err returns the first error encountered while encoding, if any
+func (e *Encoder) Err() error {
+	return e.err
+}
+
+// Grow ensures the append buffer has capacity for at least n more bytes
+func (e *Encoder) Grow(n int) {
+	if e.target != nil || cap(e.out)-len(e.out) >= n {
+		return
+	}
+	out := make([]byte, len(e.out), 2*cap(e.out)+n)
+	copy(out, e.out)
+	e.out = out
+}
+
+// Len returns the number of bytes held by an append encoder
+func (e *Encoder) Len() int {
+	return len(e.out)
+}
+
+// Reset discards the encoded bytes and any error while retaining the
+// underlying buffer
+func (e *Encoder) Reset() {
+	e.out = e.out[:0]
+	e.err = nil
+}
+
+// Truncate discards all but the first n bytes held by an append encoder
+func (e *Encoder) Truncate(n int) {
+	if !e.patchable(n, 0) {
+		return
+	}
+	e.out = e.out[:n]
+}
+
+// Reserve appends n zero bytes to be back-patched once their value is known
+// and returns their offset
+func (e *Encoder) Reserve(n int) int {
+	if e.target != nil {
+		e.SetErr(errNotAppending)
+		return 0
+	}
+
+	offset := len(e.out)
+	e.Grow(n)
+	e.out = e.out[:offset+n]
+	for i := offset; i < len(e.out); i++ {
+		e.out[i] = 0
+	}
+	return offset
+}
+
+// PatchInt32 overwrites the 4 bytes at offset with i
+func (e *Encoder) PatchInt32(offset int, i int32) {
+	if !e.patchable(offset, 4) {
+		return
+	}
+	binary.BigEndian.PutUint32(e.out[offset:], uint32(i))
+}
+
+// PatchLength overwrites the 4 bytes at offset with the number of bytes
+// encoded after them
+func (e *Encoder) PatchLength(offset int) {
+	e.PatchInt32(offset, int32(len(e.out)-offset-4))
+}
+
+// PatchCRC32C overwrites the 4 bytes at offset with the crc32c checksum, as
+// used by record batches, of the bytes encoded from from onwards
+func (e *Encoder) PatchCRC32C(offset, from int) {
+	if !e.patchable(offset, 4) || !e.patchable(from, 0) {
+		return
+	}
+	binary.BigEndian.PutUint32(e.out[offset:], CRC32C(e.out[from:]))
+}
+
 // Flush encoding buffer
 func (e *Encoder) Flush() error {
 	if e.err != nil {
@@ -67,12 +177,110 @@ func (e *Encoder) PutBool(b bool) {
 	}
 }
 
+// PutBoolArray encodes a []bool
+func (e *Encoder) PutBoolArray(items []bool) {
+	e.putArray(e.PutArrayLength, len(items), items == nil, func(i int) {
+		e.PutBool(items[i])
+	})
+}
+
 // PutBool encodes a byte array
 func (e *Encoder) PutBytes(data []byte) {
 	e.PutInt32(int32(len(data)))
 	if e.err == nil {
-		_, e.err = e.target.Write(data)
+		e.write(data)
+	}
+}
+
+// PutBytesArray encodes a [][]byte
+func (e *Encoder) PutBytesArray(items [][]byte) {
+	e.putArray(e.PutArrayLength, len(items), items == nil, func(i int) {
+		e.PutBytes(items[i])
+	})
+}
+
+// PutCompactArrayLength encodes the array length as an unsigned varint of
+// n+1; an n of -1 encodes a null array
+func (e *Encoder) PutCompactArrayLength(n int) {
+	e.putCompactLength(n)
+}
+
+// PutCompactBytes encodes a byte array using the compact encoding
+func (e *Encoder) PutCompactBytes(data []byte) {
+	e.putCompactLength(len(data))
+	if e.err == nil {
+		e.write(data)
+	}
+}
+
+// PutCompactInt32Array encodes a []int32 using the compact encoding
+func (e *Encoder) PutCompactInt32Array(items []int32) {
+	e.putArray(e.putCompactLength, len(items), items == nil, func(i int) {
+		e.PutInt32(items[i])
+	})
+}
+
+// PutCompactInt64Array encodes a []int64 using the compact encoding
+func (e *Encoder) PutCompactInt64Array(items []int64) {
+	e.putArray(e.putCompactLength, len(items), items == nil, func(i int) {
+		e.PutInt64(items[i])
+	})
+}
+
+// PutCompactNullableBytes encodes a byte array using the compact encoding;
+// nil is encoded as null
+func (e *Encoder) PutCompactNullableBytes(data []byte) {
+	if data == nil {
+		e.putCompactLength(-1)
+		return
+	}
+	e.PutCompactBytes(data)
+}
+
+// PutCompactNullableString encodes a *string using the compact encoding
+func (e *Encoder) PutCompactNullableString(s *string) {
+	if s == nil {
+		e.putCompactLength(-1)
+		return
+	}
+	e.PutCompactString(*s)
+}
+
+// PutCompactString encodes a string using the compact encoding
+func (e *Encoder) PutCompactString(s string) {
+	if len(s) > math.MaxInt16 {
+		e.SetErr(errLengthOverflow)
+		return
+	}
+
+	e.putCompactLength(len(s))
+	if e.err == nil {
+		e.writeString(s)
+	}
+}
+
+// PutCompactStringArray encodes a []string using the compact encoding
+func (e *Encoder) PutCompactStringArray(items []string) {
+	e.putArray(e.putCompactLength, len(items), items == nil, func(i int) {
+		e.PutCompactString(items[i])
+	})
+}
+
+// PutFloat64 encodes a float64
+func (e *Encoder) PutFloat64(f float64) {
+	if e.err != nil {
+		return
 	}
+
+	binary.BigEndian.PutUint64(e.buf[:8], math.Float64bits(f))
+	e.write(e.buf[:8])
+}
+
+// PutFloat64Array encodes a []float64
+func (e *Encoder) PutFloat64Array(items []float64) {
+	e.putArray(e.PutArrayLength, len(items), items == nil, func(i int) {
+		e.PutFloat64(items[i])
+	})
 }
 
 // PutInt8 encodes an int8
@@ -82,7 +290,14 @@ func (e *Encoder) PutInt8(i int8) {
 	}
 
 	e.buf[0] = byte(i)
-	_, e.err = e.target.Write(e.buf[:1])
+	e.write(e.buf[:1])
+}
+
+// PutInt8Array encodes a []int8
+func (e *Encoder) PutInt8Array(items []int8) {
+	e.putArray(e.PutArrayLength, len(items), items == nil, func(i int) {
+		e.PutInt8(items[i])
+	})
 }
 
 // PutInt16 encodes an int16
@@ -92,7 +307,14 @@ func (e *Encoder) PutInt16(i int16) {
 	}
 
 	binary.BigEndian.PutUint16(e.buf[:2], uint16(i))
-	_, e.err = e.target.Write(e.buf[:2])
+	e.write(e.buf[:2])
+}
+
+// PutInt16Array encodes a []int16
+func (e *Encoder) PutInt16Array(items []int16) {
+	e.putArray(e.PutArrayLength, len(items), items == nil, func(i int) {
+		e.PutInt16(items[i])
+	})
 }
 
 // PutInt32 encodes an int32
@@ -102,25 +324,14 @@ func (e *Encoder) PutInt32(i int32) {
 	}
 
 	binary.BigEndian.PutUint32(e.buf[:4], uint32(i))
-	_, e.err = e.target.Write(e.buf[:4])
+	e.write(e.buf[:4])
 }
 
-// PutInt32Array encodes an []int32
-func (e *Encoder) PutInt32Array(ii []int32) {
-	if e.err != nil {
-		return
-	}
-
-	if ii == nil {
-		e.PutInt32(-1)
-		return
-	}
-
-	length := len(ii)
-	e.PutArrayLength(length)
-	for _, i := range ii {
-		e.PutInt32(i)
-	}
+// PutInt32Array encodes a []int32
+func (e *Encoder) PutInt32Array(items []int32) {
+	e.putArray(e.PutArrayLength, len(items), items == nil, func(i int) {
+		e.PutInt32(items[i])
+	})
 }
 
 // PutInt64 encodes an int64
@@ -130,25 +341,23 @@ func (e *Encoder) PutInt64(i int64) {
 	}
 
 	binary.BigEndian.PutUint64(e.buf[:8], uint64(i))
-	_, e.err = e.target.Write(e.buf[:8])
+	e.write(e.buf[:8])
 }
 
-// PutInt64Array encodes an []int64
-func (e *Encoder) PutInt64Array(ii []int64) {
-	if e.err != nil {
-		return
-	}
+// PutInt64Array encodes a []int64
+func (e *Encoder) PutInt64Array(items []int64) {
+	e.putArray(e.PutArrayLength, len(items), items == nil, func(i int) {
+		e.PutInt64(items[i])
+	})
+}
 
-	if ii == nil {
+// PutNullableBytes encodes a byte array; nil is encoded as null
+func (e *Encoder) PutNullableBytes(data []byte) {
+	if data == nil {
 		e.PutInt32(-1)
 		return
 	}
-
-	length := len(ii)
-	e.PutArrayLength(length)
-	for _, i := range ii {
-		e.PutInt64(i)
-	}
+	e.PutBytes(data)
 }
 
 // PutNullableString encodes a *string
@@ -160,6 +369,20 @@ func (e *Encoder) PutNullableString(s *string) {
 	e.PutString(*s)
 }
 
+// PutRaw unlike PutBytes puts the bytes as is without first encoding the length
+func (e *Encoder) PutRaw(data []byte) {
+	if e.err != nil {
+		return
+	}
+
+	e.write(data)
+}
+
+// PutRecords encodes an unparsed record set as nullable bytes
+func (e *Encoder) PutRecords(data []byte) {
+	e.PutNullableBytes(data)
+}
+
 // PutRecordHeader puts a single record header element onto the stream
 func (e *Encoder) PutRecordHeader(key, value string) {
 	if e.err != nil {
@@ -174,36 +397,52 @@ func (e *Encoder) PutRecordHeader(key, value string) {
 func (e *Encoder) PutString(s string) {
 	e.PutInt16(int16(len(s)))
 	if e.err == nil {
-		_, e.err = io.WriteString(e.target, s)
+		e.writeString(s)
 	}
 }
 
 // PutStringArray encodes a []string
-func (e *Encoder) PutStringArray(ss []string) {
+func (e *Encoder) PutStringArray(items []string) {
+	e.putArray(e.PutArrayLength, len(items), items == nil, func(i int) {
+		e.PutString(items[i])
+	})
+}
+
+// PutUuid encodes a UUID
+func (e *Encoder) PutUuid(u UUID) {
 	if e.err != nil {
 		return
 	}
 
-	if ss == nil {
-		e.PutInt32(-1)
+	e.write(u[:])
+}
+
+// PutUuidArray encodes a []UUID
+func (e *Encoder) PutUuidArray(items []UUID) {
+	e.putArray(e.PutArrayLength, len(items), items == nil, func(i int) {
+		e.PutUuid(items[i])
+	})
+}
+
+// PutUvarint encodes an unsigned var int
+func (e *Encoder) PutUvarint(u uint64) {
+	if e.err != nil {
 		return
 	}
 
-	length := len(ss)
-	e.PutArrayLength(length)
-	for _, s := range ss {
-		e.PutString(s)
-	}
+	length := binary.PutUvarint(e.buf[:], u)
+	e.write(e.buf[0:length])
 }
 
-// PutRaw unlike PutBytes puts the bytes as is without first encoding the length
+// PutVarBytes encodes data preceded by its length as a var int, as used by
+// the keys and values of records
 func (e *Encoder) PutVarBytes(data []byte) {
 	if e.err != nil {
 		return
 	}
 
 	e.PutVarInt(int64(len(data)))
-	_, e.err = e.target.Write(data)
+	e.write(data)
 }
 
 // PutVarInt encodes a var int
@@ -213,21 +452,90 @@ func (e *Encoder) PutVarInt(i int64) {
 	}
 
 	length := binary.PutVarint(e.buf[:], i)
-	_, e.err = e.target.Write(e.buf[0:length])
+	e.write(e.buf[0:length])
 }
 
-// PutRaw unlike PutBytes puts the bytes as is without first encoding the length
+// PutVarLong encodes a var long as used by record batches
+func (e *Encoder) PutVarLong(i int64) {
+	e.PutVarInt(i)
+}
+
+// PutVarString encodes s preceded by its length as a var int, as used by the
+// headers of records
 func (e *Encoder) PutVarString(s string) {
 	if e.err != nil {
 		return
 	}
 
 	e.PutVarInt(int64(len(s)))
-	for _, i := range s {
-		length := utf8.EncodeRune(e.buf[:], rune(i))
-		_, e.err = e.target.Write(e.buf[0:length])
-		if e.err != nil {
-			return
-		}
+	e.writeString(s)
+}
+
+// write appends data to the buffer or writes it to the target
+func (e *Encoder) write(data []byte) {
+	if e.target == nil {
+		e.out = append(e.out, data...)
+		return
+	}
+	_, e.err = e.target.Write(data)
+}
+
+// writeString is write for strings without the conversion to []byte
+func (e *Encoder) writeString(s string) {
+	if e.target == nil {
+		e.out = append(e.out, s...)
+		return
+	}
+	_, e.err = io.WriteString(e.target, s)
+}
+
+// patchable returns true if n bytes at offset may be back-patched
+func (e *Encoder) patchable(offset, n int) bool {
+	switch {
+	case e.err != nil:
+		return false
+	case e.target != nil:
+		e.SetErr(errNotAppending)
+		return false
+	case offset < 0 || offset+n > len(e.out):
+		e.SetErr(errPatchOffset)
+		return false
+	}
+	return true
+}
+
+// putCompactLength encodes n+1 as an unsigned varint so that a null length of
+// -1 is encoded as 0
+func (e *Encoder) putCompactLength(n int) {
+	if n < -1 || int64(n) >= math.MaxInt32 {
+		e.SetErr(errLengthOverflow)
+		return
+	}
+	e.PutUvarint(uint64(n + 1))
+}
+
+// SetErr records err unless an earlier error has already been recorded.
+// Types encoded outside of this package use SetErr to report errors.
+func (e *Encoder) SetErr(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+// putArray encodes the array length, using putLength, followed by each of
+// the n elements; a null array is encoded as a length of -1
+func (e *Encoder) putArray(putLength func(n int), n int, null bool, encode func(i int)) {
+	if e.err != nil {
+		return
+	}
+
+	if null {
+		putLength(-1)
+		return
+	}
+
+	putLength(n)
+	for i := 0; i < n && e.err == nil; i++ {
+		encode(i)
 	}
 }
@@ -17,8 +17,14 @@
 package message
 
 import (
+	"bufio"
 	"bytes"
 	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -73,6 +79,32 @@ func TestEncoder_err(t *testing.T) {
 
 	e.PutVarString("hello world")
 
+	e.PutCompactArrayLength(1)
+
+	e.PutCompactBytes(data)
+
+	e.PutCompactInt32Array(ii32)
+
+	e.PutCompactInt64Array(ii64)
+
+	e.PutCompactNullableBytes(data)
+
+	e.PutCompactNullableString(sp)
+
+	e.PutCompactString(s)
+
+	e.PutCompactStringArray(ss)
+
+	e.PutUvarint(123)
+
+	e.PutVarLong(123)
+
+	e.PutFloat64(1.5)
+
+	e.PutRecords(data)
+
+	e.PutUuid(UUID{})
+
 	if got, want := buf.Len(), 0; got != want {
 		t.Fatalf("got %v; want %v", got, want)
 	}
@@ -81,3 +113,313 @@ func TestEncoder_err(t *testing.T) {
 		t.Fatalf("got %v; want %v", got, wantErr)
 	}
 }
+
+func TestEncoder_lengthOverflow(t *testing.T) {
+	testCases := map[string]func(e *Encoder){
+		"string": func(e *Encoder) {
+			e.PutCompactString(strings.Repeat("a", math.MaxInt16+1))
+		},
+		"negative array length": func(e *Encoder) {
+			e.PutCompactArrayLength(-2)
+		},
+	}
+
+	for label, fn := range testCases {
+		t.Run(label, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			e := NewEncoder(buf)
+			fn(e)
+
+			if got, want := e.Flush(), errLengthOverflow; got != want {
+				t.Fatalf("got %v; want %v", got, want)
+			}
+			if got, want := buf.Len(), 0; got != want {
+				t.Fatalf("got %v; want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestAppendEncoder(t *testing.T) {
+	s := "hello"
+	encode := func(e *Encoder) {
+		e.PutBool(true)
+		e.PutBytes([]byte("abc"))
+		e.PutCompactString("compact")
+		e.PutFloat64(1.5)
+		e.PutInt16Array([]int16{1, 2, 3})
+		e.PutInt64(math.MaxInt64)
+		e.PutNullableString(&s)
+		e.PutRecords(nil)
+		e.PutUuid(UUID{1, 2, 3})
+		e.PutVarInt(-300)
+		e.PutVarString("varstring")
+	}
+
+	buf := bytes.NewBuffer(nil)
+	w := NewEncoder(buf)
+	encode(w)
+	if err := w.Flush(); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	var e Encoder
+	encode(&e)
+	if err := e.Flush(); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if got, want := e.Bytes(), buf.Bytes(); !bytes.Equal(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+	if got, want := e.Len(), buf.Len(); got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+
+	e.Reset()
+	if got, want := e.Len(), 0; got != want {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+}
+
+func TestAppendEncoder_grow(t *testing.T) {
+	e := NewAppendEncoder(nil)
+	e.Grow(64)
+	if got, want := cap(e.Bytes()), 64; got < want {
+		t.Fatalf("got %v; want at least %v", got, want)
+	}
+
+	e.PutString("hello")
+	e.Grow(1024)
+	if got, want := cap(e.Bytes())-e.Len(), 1024; got < want {
+		t.Fatalf("got %v; want at least %v", got, want)
+	}
+	if got, want := e.Bytes(), []byte("\x00\x05hello"); !bytes.Equal(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+}
+
+func TestAppendEncoder_patch(t *testing.T) {
+	e := NewAppendEncoder(nil)
+	length := e.Reserve(4)
+	e.PutInt8(1)
+	crc := e.Reserve(4)
+	from := e.Len()
+	e.writeString("123456789")
+	e.PatchLength(length)
+	e.PatchCRC32C(crc, from)
+
+	if err := e.Err(); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	want := []byte{0, 0, 0, 14, 1, 0xe3, 0x06, 0x92, 0x83}
+	want = append(want, "123456789"...)
+	if got := e.Bytes(); !bytes.Equal(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+}
+
+func TestAppendEncoder_truncate(t *testing.T) {
+	e := NewAppendEncoder(nil)
+	e.PutRaw([]byte("hello"))
+	e.Truncate(2)
+	e.PutRaw([]byte("y"))
+
+	if err := e.Err(); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if got, want := e.Bytes(), []byte("hey"); !bytes.Equal(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+}
+
+func TestAppendEncoder_patchErr(t *testing.T) {
+	testCases := map[string]struct {
+		Encoder *Encoder
+		Patch   func(e *Encoder)
+		Want    error
+	}{
+		"writer": {
+			Encoder: NewEncoder(ioutil.Discard),
+			Patch:   func(e *Encoder) { e.Reserve(4) },
+			Want:    errNotAppending,
+		},
+		"offset": {
+			Encoder: NewAppendEncoder(nil),
+			Patch:   func(e *Encoder) { e.PatchInt32(0, 1) },
+			Want:    errPatchOffset,
+		},
+		"crc from": {
+			Encoder: NewAppendEncoder(make([]byte, 4)),
+			Patch:   func(e *Encoder) { e.PatchCRC32C(0, 5) },
+			Want:    errPatchOffset,
+		},
+		"truncate": {
+			Encoder: NewAppendEncoder(make([]byte, 4)),
+			Patch:   func(e *Encoder) { e.Truncate(5) },
+			Want:    errPatchOffset,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			tc.Patch(tc.Encoder)
+			if got, want := tc.Encoder.Err(), tc.Want; got != want {
+				t.Fatalf("got %v; want %v", got, want)
+			}
+		})
+	}
+}
+
+// testMessage is implemented by every generated message
+type testMessage interface {
+	Encode(e *Encoder, version int16)
+	Size(version int16) int32
+	Decode(d *Decoder, version int16) error
+	Reset()
+	StringVersion(version int16) string
+	MarshalJSONVersion(version int16) ([]byte, error)
+	UnmarshalJSONVersion(data []byte, version int16) error
+	Validate(version int16) error
+}
+
+// fillMode selects the values written by fill
+type fillMode int
+
+const (
+	fillPopulate fillMode = iota // representative values; slices hold n elements
+	fillRandom                   // random values; slices are nil or hold up to n elements
+	fillZero                     // zero values, in place, leaving slices and pointers as they are
+)
+
+// fill writes values chosen by mode to v and everything reachable from v; r
+// is only used by fillRandom
+func fill(v reflect.Value, mode fillMode, n int, r *rand.Rand) {
+	switch v.Kind() {
+	case reflect.Bool:
+		switch mode {
+		case fillPopulate:
+			v.SetBool(true)
+		case fillRandom:
+			v.SetBool(r.Intn(2) == 1)
+		default:
+			v.SetBool(false)
+		}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch {
+		case mode == fillRandom:
+			v.SetInt(int64(r.Uint64()) >> uint(r.Intn(64))) // truncated to the width of v
+		case mode == fillZero:
+			v.SetInt(0)
+		case v.Kind() == reflect.Int64:
+			v.SetInt(8e6) // a whole number of milliseconds survives mapping to time.Duration
+		default:
+			v.SetInt(int64(v.Type().Size()))
+		}
+	case reflect.Uint8:
+		switch mode {
+		case fillPopulate:
+			v.SetUint(1)
+		case fillRandom:
+			v.SetUint(uint64(r.Intn(256)))
+		default:
+			v.SetUint(0)
+		}
+	case reflect.Float64:
+		switch mode {
+		case fillPopulate:
+			v.SetFloat(1.5)
+		case fillRandom:
+			v.SetFloat(r.NormFloat64())
+		default:
+			v.SetFloat(0)
+		}
+	case reflect.String:
+		switch mode {
+		case fillPopulate:
+			v.SetString("value")
+		case fillRandom:
+			runes := []rune("ab€𝄞") // multi byte runes
+			s := make([]rune, r.Intn(8))
+			for i := range s {
+				s[i] = runes[r.Intn(len(runes))]
+			}
+			v.SetString(string(s))
+		default:
+			v.SetString("")
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fill(v.Index(i), mode, n, r)
+		}
+	case reflect.Ptr:
+		switch {
+		case mode == fillZero:
+			if v.IsNil() {
+				return
+			}
+		case mode == fillRandom && r.Intn(4) == 0:
+			v.Set(reflect.Zero(v.Type()))
+			return
+		default:
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		fill(v.Elem(), mode, n, r)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				fill(v.Field(i), mode, n, r)
+			}
+		}
+	case reflect.Slice:
+		switch {
+		case mode == fillZero:
+		case mode == fillRandom && r.Intn(4) == 0:
+			v.Set(reflect.Zero(v.Type()))
+			return
+		case mode == fillPopulate && v.Type().Elem().Kind() == reflect.Uint8:
+			v.SetBytes(bytes.Repeat([]byte("x"), 1024))
+			return
+		case mode == fillRandom:
+			k := r.Intn(n + 1)
+			v.Set(reflect.MakeSlice(v.Type(), k, k))
+		default:
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+		}
+		for i := 0; i < v.Len(); i++ {
+			fill(v.Index(i), mode, n, r)
+		}
+	}
+}
+
+// benchmarkEncode compares encoding m through a buffered io.Writer with
+// encoding m into a pre-sized append encoder
+func benchmarkEncode(b *testing.B, m testMessage, version int16) {
+	fill(reflect.ValueOf(m).Elem(), fillPopulate, 4, nil)
+	size := int(m.Size(version))
+
+	b.Run("writer", func(b *testing.B) {
+		e := NewEncoder(bufio.NewWriter(ioutil.Discard))
+		b.SetBytes(int64(size))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m.Encode(e, version)
+			if err := e.Flush(); err != nil {
+				b.Fatalf("got %v; want nil", err)
+			}
+		}
+	})
+
+	b.Run("append", func(b *testing.B) {
+		e := NewAppendEncoder(make([]byte, 0, size))
+		b.SetBytes(int64(size))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			e.Reset()
+			m.Encode(e, version)
+			if err := e.Err(); err != nil {
+				b.Fatalf("got %v; want nil", err)
+			}
+		}
+	})
+}
@@ -24,14 +24,108 @@ const (
 	Int16       int32 = 2     // Int16 size
 	Int32       int32 = 4     // Int32 bytes
 	Int64       int32 = 8     // Int64 bytes
+	Float64     int32 = 8     // Float64 bytes
+	Uuid        int32 = 16    // Uuid bytes
 	ArrayLength       = Int32 // Arraylength bytes e.g. Int32
 )
 
+// BoolArray returns size of []bool
+func BoolArray(items []bool) int32 {
+	return ArrayLength + int32(len(items))*Bool
+}
+
 // Bytes returns size of []byte
 func Bytes(data []byte) int32 {
 	return ArrayLength + int32(len(data)) // int32 length + length of bytes
 }
 
+// BytesArray returns size of [][]byte
+func BytesArray(items [][]byte) int32 {
+	sz := ArrayLength
+	for _, item := range items {
+		sz += Bytes(item)
+	}
+	return sz
+}
+
+// CompactArrayLength returns the size of the length of a compact array of n
+// elements; an n of -1 indicates a null array
+func CompactArrayLength(n int) int32 {
+	return Uvarint(uint64(n + 1))
+}
+
+// CompactBytes returns size of a compact []byte
+func CompactBytes(data []byte) int32 {
+	length := len(data)
+	return CompactArrayLength(length) + int32(length)
+}
+
+// CompactInt32Array returns size of a compact []int32
+func CompactInt32Array(ii []int32) int32 {
+	if ii == nil {
+		return CompactArrayLength(-1)
+	}
+	return CompactArrayLength(len(ii)) + int32(len(ii))*Int32
+}
+
+// CompactInt64Array returns size of a compact []int64
+func CompactInt64Array(ii []int64) int32 {
+	if ii == nil {
+		return CompactArrayLength(-1)
+	}
+	return CompactArrayLength(len(ii)) + int32(len(ii))*Int64
+}
+
+// CompactNullableBytes returns size of a compact nullable []byte
+func CompactNullableBytes(data []byte) int32 {
+	if data == nil {
+		return CompactArrayLength(-1)
+	}
+	return CompactBytes(data)
+}
+
+// CompactNullableString returns size of a compact *string
+func CompactNullableString(s *string) int32 {
+	if s == nil {
+		return CompactArrayLength(-1)
+	}
+	return CompactString(*s)
+}
+
+// CompactString returns size of a compact string
+func CompactString(s string) int32 {
+	length := len(s)
+	return CompactArrayLength(length) + int32(length)
+}
+
+// CompactStringArray returns size of a compact []string
+func CompactStringArray(ss []string) int32 {
+	if ss == nil {
+		return CompactArrayLength(-1)
+	}
+
+	sz := CompactArrayLength(len(ss))
+	for _, s := range ss {
+		sz += CompactString(s)
+	}
+	return sz
+}
+
+// Float64Array returns size of []float64
+func Float64Array(items []float64) int32 {
+	return ArrayLength + int32(len(items))*Float64
+}
+
+// Int8Array returns size of []int8
+func Int8Array(items []int8) int32 {
+	return ArrayLength + int32(len(items))*Int8
+}
+
+// Int16Array returns size of []int16
+func Int16Array(items []int16) int32 {
+	return ArrayLength + int32(len(items))*Int16
+}
+
 // Int32Array returns size of []int32
 func Int32Array(ii []int32) int32 {
 	return ArrayLength + int32(len(ii))*Int32 // int32 length + length of array * int32 length
@@ -42,6 +136,30 @@ func Int64Array(ii []int64) int32 {
 	return ArrayLength + int32(len(ii))*Int64 // int32 length + length of array * int64 length
 }
 
+// NullableBytes returns size of a nullable []byte; nil is encoded as a
+// length of -1
+func NullableBytes(data []byte) int32 {
+	return Bytes(data)
+}
+
+// NullableString returns size of a *string; nil is encoded as a length of -1
+func NullableString(s *string) int32 {
+	if s == nil {
+		return Int16
+	}
+	return String(*s)
+}
+
+// RecordHeader returns size of a record header
+func RecordHeader(key, value string) int32 {
+	return VarString(key) + VarString(value)
+}
+
+// Records returns size of an unparsed record set
+func Records(data []byte) int32 {
+	return Bytes(data)
+}
+
 // String returns size of string
 func String(s string) int32 {
 	return Int16 + int32(len(s))
@@ -57,6 +175,13 @@ func StringArray(ss []string) int32 {
 	return sz
 }
 
+// Uvarint returns the length of an unsigned var int
+func Uvarint(u uint64) int32 {
+	var buf [binary.MaxVarintLen64]byte
+	length := binary.PutUvarint(buf[:], u)
+	return int32(length)
+}
+
 // VarBytes returns the length of a var int
 func VarBytes(data []byte) int32 {
 	length := len(data)
@@ -70,6 +195,11 @@ func VarInt(i int64) int32 {
 	return int32(length)
 }
 
+// VarLong returns the length of a var long
+func VarLong(i int64) int32 {
+	return VarInt(i)
+}
+
 // VarString returns the length of a var string
 func VarString(s string) int32 {
 	length := len(s)
@@ -210,3 +210,200 @@ func TestVarString(t *testing.T) {
 		})
 	}
 }
+
+func TestUvarint(t *testing.T) {
+	tests := []struct {
+		name string
+		u    uint64
+		want int32
+	}{
+		{
+			name: "1 byte",
+			u:    127,
+			want: 1,
+		},
+		{
+			name: "2 bytes",
+			u:    128,
+			want: 2,
+		},
+		{
+			name: "10 bytes",
+			u:    math.MaxUint64,
+			want: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Uvarint(tt.u); got != tt.want {
+				t.Errorf("Uvarint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompactString(t *testing.T) {
+	var some = "hello world"
+
+	tests := []struct {
+		name string
+		fn   func() int32
+		want int32
+	}{
+		{
+			name: "blank",
+			fn:   func() int32 { return CompactString("") },
+			want: 1,
+		},
+		{
+			name: "some",
+			fn:   func() int32 { return CompactString(some) },
+			want: 12,
+		},
+		{
+			name: "null",
+			fn:   func() int32 { return CompactNullableString(nil) },
+			want: 1,
+		},
+		{
+			name: "nullable",
+			fn:   func() int32 { return CompactNullableString(&some) },
+			want: 12,
+		},
+		{
+			name: "long",
+			fn:   func() int32 { return CompactString(string(make([]byte, 127))) },
+			want: 129,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(); got != tt.want {
+				t.Errorf("CompactString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompactArrays(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func() int32
+		want int32
+	}{
+		{
+			name: "null int32 array",
+			fn:   func() int32 { return CompactInt32Array(nil) },
+			want: 1,
+		},
+		{
+			name: "int32 array",
+			fn:   func() int32 { return CompactInt32Array([]int32{1, 2}) },
+			want: 9,
+		},
+		{
+			name: "int64 array",
+			fn:   func() int32 { return CompactInt64Array([]int64{1, 2}) },
+			want: 17,
+		},
+		{
+			name: "string array",
+			fn:   func() int32 { return CompactStringArray([]string{"a", "bc"}) },
+			want: 6,
+		},
+		{
+			name: "null bytes",
+			fn:   func() int32 { return CompactNullableBytes(nil) },
+			want: 1,
+		},
+		{
+			name: "bytes",
+			fn:   func() int32 { return CompactBytes([]byte("abc")) },
+			want: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecords(t *testing.T) {
+	if got, want := Records(nil), ArrayLength; got != want {
+		t.Errorf("Records() = %v, want %v", got, want)
+	}
+	if got, want := Records([]byte("abc")), ArrayLength+3; got != want {
+		t.Errorf("Records() = %v, want %v", got, want)
+	}
+}
+
+func TestPrimitiveArrays(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func() int32
+		want int32
+	}{
+		{
+			name: "bool",
+			fn:   func() int32 { return BoolArray([]bool{true, false}) },
+			want: 6,
+		},
+		{
+			name: "bytes",
+			fn:   func() int32 { return BytesArray([][]byte{[]byte("abc"), nil}) },
+			want: 15,
+		},
+		{
+			name: "float64",
+			fn:   func() int32 { return Float64Array([]float64{1}) },
+			want: 12,
+		},
+		{
+			name: "int8",
+			fn:   func() int32 { return Int8Array([]int8{1, 2, 3}) },
+			want: 7,
+		},
+		{
+			name: "int16",
+			fn:   func() int32 { return Int16Array([]int16{1, 2, 3}) },
+			want: 10,
+		},
+		{
+			name: "nil",
+			fn:   func() int32 { return Int16Array(nil) },
+			want: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNullable(t *testing.T) {
+	s := "abc"
+	if got, want := NullableString(nil), Int16; got != want {
+		t.Errorf("NullableString() = %v, want %v", got, want)
+	}
+	if got, want := NullableString(&s), Int16+3; got != want {
+		t.Errorf("NullableString() = %v, want %v", got, want)
+	}
+	if got, want := NullableBytes(nil), ArrayLength; got != want {
+		t.Errorf("NullableBytes() = %v, want %v", got, want)
+	}
+	if got, want := NullableBytes([]byte(s)), ArrayLength+3; got != want {
+		t.Errorf("NullableBytes() = %v, want %v", got, want)
+	}
+}
+
+func TestRecordHeader(t *testing.T) {
+	if got, want := RecordHeader("key", "value"), int32(1+3+1+5); got != want {
+		t.Errorf("RecordHeader() = %v, want %v", got, want)
+	}
+}